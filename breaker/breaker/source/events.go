@@ -0,0 +1,177 @@
+package source
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 订阅通道的缓冲大小，订阅方消费过慢时丢弃事件，不阻塞请求
+const eventBufferSize = 128
+
+// 熔断器状态，google 算法开始丢弃请求时为打开，丢弃概率回落到 0 时为关闭
+const (
+	StateClosed State = iota
+	StateOpen
+)
+
+// 熔断器事件类型
+const (
+	// EventAccept 请求被放行且成功
+	EventAccept EventType = iota
+	// EventReject 请求被放行但失败
+	EventReject
+	// EventDrop 请求被熔断器丢弃
+	EventDrop
+	// EventIgnore 请求被放行，但结果不计入统计，例如调用方主动取消
+	EventIgnore
+)
+
+type (
+	// State 熔断器状态
+	State int
+
+	// Stats 熔断器在统计窗口内的请求情况
+	Stats struct {
+		Requests int64 // 请求总数
+		Failures int64 // 失败数
+	}
+
+	// StateChangeHandler 熔断器状态变化时的回调
+	StateChangeHandler func(name string, from, to State, stats Stats)
+
+	// EventType 熔断器事件类型
+	EventType int
+
+	// Event 熔断器事件
+	Event struct {
+		Name   string    // 熔断器名称
+		Type   EventType // 事件类型
+		Reason string    // 失败、丢弃或忽略的原因
+		Time   time.Time
+	}
+
+	// notifier 管理状态变化回调与事件订阅
+	notifier struct {
+		lock        sync.RWMutex
+		handlers    []StateChangeHandler
+		subscribers []chan Event
+		// 是否有订阅方，没有订阅时跳过事件的构造
+		subscribed int32
+	}
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+func (t EventType) String() string {
+	switch t {
+	case EventAccept:
+		return "accept"
+	case EventReject:
+		return "reject"
+	case EventDrop:
+		return "drop"
+	case EventIgnore:
+		return "ignore"
+	default:
+		return "unknown"
+	}
+}
+
+// OnStateChange 注册状态变化回调，开始丢弃请求或恢复时同步调用
+func (b *GoogleBreaker) OnStateChange(fn StateChangeHandler) {
+	b.notifier.lock.Lock()
+	b.notifier.handlers = append(b.notifier.handlers, fn)
+	b.notifier.lock.Unlock()
+}
+
+// Subscribe 订阅请求的放行、失败、丢弃事件，订阅方消费过慢时丢弃事件
+// ctx 结束时取消订阅并关闭通道
+func (b *GoogleBreaker) Subscribe(ctx context.Context) <-chan Event {
+	ch := make(chan Event, eventBufferSize)
+
+	b.notifier.lock.Lock()
+	b.notifier.subscribers = append(b.notifier.subscribers, ch)
+	atomic.StoreInt32(&b.notifier.subscribed, 1)
+	b.notifier.lock.Unlock()
+
+	// ctx 永远不会结束时无需等待，避免泄漏 goroutine
+	if ctx.Done() != nil {
+		go func() {
+			<-ctx.Done()
+			b.notifier.unsubscribe(ch)
+		}()
+	}
+
+	return ch
+}
+
+// unsubscribe 移除订阅并关闭通道，持有写锁时关闭，emit 不会向已关闭的通道发送
+func (n *notifier) unsubscribe(ch chan Event) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	for i, sub := range n.subscribers {
+		if sub == ch {
+			n.subscribers = append(n.subscribers[:i], n.subscribers[i+1:]...)
+			close(ch)
+			break
+		}
+	}
+	if len(n.subscribers) == 0 {
+		atomic.StoreInt32(&n.subscribed, 0)
+	}
+}
+
+// transit 切换状态，状态变化时回调
+func (b *GoogleBreaker) transit(to State, accepts, requests int64) {
+	from := State(atomic.SwapInt32(&b.state, int32(to)))
+	if from == to {
+		return
+	}
+
+	b.notifier.lock.RLock()
+	handlers := b.notifier.handlers
+	b.notifier.lock.RUnlock()
+
+	stats := Stats{
+		Requests: requests,
+		Failures: requests - accepts,
+	}
+	for _, handler := range handlers {
+		handler(b.name, from, to, stats)
+	}
+}
+
+// emit 向全部订阅方发送事件，通道已满时丢弃
+func (b *GoogleBreaker) emit(typ EventType, reason string) {
+	if atomic.LoadInt32(&b.notifier.subscribed) == 0 {
+		return
+	}
+
+	event := Event{
+		Name:   b.name,
+		Type:   typ,
+		Reason: reason,
+		Time:   time.Now(),
+	}
+
+	b.notifier.lock.RLock()
+	defer b.notifier.lock.RUnlock()
+	for _, ch := range b.notifier.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
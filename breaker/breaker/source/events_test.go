@@ -0,0 +1,77 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go-zero-source/util/clock/clocktest"
+	"testing"
+	"time"
+)
+
+func TestGoogleBreakerOnStateChange(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	b := NewGoogleBreaker(WithName("dep"), WithClock(clk))
+	assert.Equal(t, "dep", b.Name())
+
+	var states []State
+	b.OnStateChange(func(name string, from, to State, stats Stats) {
+		assert.Equal(t, "dep", name)
+		if to == StateOpen {
+			assert.Equal(t, stats.Requests, stats.Failures)
+		}
+		states = append(states, from, to)
+	})
+
+	// 持续失败，直到开始丢弃请求
+	errFail := errors.New("fail")
+	for i := 0; i < 1000 && len(states) == 0; i++ {
+		_ = b.Do(func() error {
+			return errFail
+		})
+	}
+	assert.Equal(t, []State{StateClosed, StateOpen}, states)
+
+	// 窗口滑过后恢复
+	clk.Advance(window)
+	assert.Nil(t, b.Do(func() error {
+		return nil
+	}))
+	assert.Equal(t, []State{StateClosed, StateOpen, StateOpen, StateClosed}, states)
+}
+
+func TestGoogleBreakerSubscribe(t *testing.T) {
+	b := NewGoogleBreaker(WithName("dep"))
+	events := b.Subscribe(context.Background())
+
+	errFail := errors.New("fail")
+	_ = b.Do(func() error {
+		return nil
+	})
+	_ = b.Do(func() error {
+		return errFail
+	})
+
+	e := <-events
+	assert.Equal(t, "dep", e.Name)
+	assert.Equal(t, EventAccept, e.Type)
+	e = <-events
+	assert.Equal(t, EventReject, e.Type)
+	assert.Equal(t, errFail.Error(), e.Reason)
+}
+
+func TestGoogleBreakerUnsubscribe(t *testing.T) {
+	b := NewGoogleBreaker()
+	ctx, cancel := context.WithCancel(context.Background())
+	events := b.Subscribe(ctx)
+	other := b.Subscribe(context.Background())
+
+	// 取消后通道被关闭，不再接收事件
+	cancel()
+	for range events {
+	}
+	assert.Nil(t, b.Do(func() error {
+		return nil
+	}))
+	assert.Equal(t, EventAccept, (<-other).Type)
+}
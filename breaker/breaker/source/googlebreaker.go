@@ -1,8 +1,12 @@
 package source
 
 import (
+	"context"
 	"errors"
-	"github.com/zeromicro/go-zero/core/collection"
+	"fmt"
+	"github.com/zeromicro/go-zero/core/stringx"
+	"go-zero-source/rollingwindow/go-zero"
+	"go-zero-source/util/clock"
 	"math"
 	"math/rand"
 	"time"
@@ -12,41 +16,185 @@ var (
 	// ErrBreakerOpen 熔断器打开错误
 	ErrBreakerOpen = errors.New("circuit breaker is open")
 
-	// 倍率
+	// 默认倍率
 	k = 1.5
-	// 时间轮总共记录10s
+	// 时间轮默认总共记录10s
 	window = time.Second * 10
-	// 总共40个桶，等于每个桶250ms
+	// 默认总共40个桶，等于每个桶250ms
 	buckets = 40
 )
 
+// Acceptable 判断请求结果是否为成功调用
+type Acceptable func(err error) bool
+
 // GoogleBreaker Google 算法的熔断器
 // https://landing.google.com/sre/sre-book/chapters/handling-overload/
 type GoogleBreaker struct {
+	name          string
 	k             float64                   // 倍率，默认 1.5
+	minRequests   int64                     // 最小请求数，窗口内请求数不足时不丢弃请求
+	acceptable    Acceptable                // 判断请求是否成功，默认 err == nil
+	slowThreshold time.Duration             // 慢调用阈值，耗时超过阈值的请求计为失败，为 0 时不检测
 	rollingWindow *collection.RollingWindow // 时间轮，负责收集错误率
+	state         int32                     // 当前状态
+	clock         clock.Clock
+	notifier      notifier
+}
+
+type GoogleBreakerOption func(opt *googleBreakerOptions)
+
+type googleBreakerOptions struct {
+	name          string
+	k             float64
+	window        time.Duration
+	buckets       int
+	minRequests   int64
+	acceptable    Acceptable
+	slowThreshold time.Duration
+	clock         clock.Clock
+}
+
+// WithName 设置熔断器名称，默认随机生成
+func WithName(name string) GoogleBreakerOption {
+	return func(opt *googleBreakerOptions) {
+		opt.name = name
+	}
+}
+
+// WithK 设置倍率，越小越容易丢弃请求，默认 1.5
+func WithK(k float64) GoogleBreakerOption {
+	return func(opt *googleBreakerOptions) {
+		opt.k = k
+	}
+}
+
+// WithWindow 设置统计窗口长度，默认 10s
+func WithWindow(window time.Duration) GoogleBreakerOption {
+	return func(opt *googleBreakerOptions) {
+		opt.window = window
+	}
+}
+
+// WithBuckets 设置统计窗口的桶数量，默认 40
+func WithBuckets(buckets int) GoogleBreakerOption {
+	return func(opt *googleBreakerOptions) {
+		opt.buckets = buckets
+	}
+}
+
+// WithMinRequests 设置最小请求数，窗口内请求数不足时不丢弃请求，避免低流量的依赖因为几次失败就被熔断，默认 0
+func WithMinRequests(n int64) GoogleBreakerOption {
+	return func(opt *googleBreakerOptions) {
+		opt.minRequests = n
+	}
+}
+
+// WithAcceptable 设置判断请求是否成功的函数，默认 err == nil
+func WithAcceptable(acceptable Acceptable) GoogleBreakerOption {
+	return func(opt *googleBreakerOptions) {
+		opt.acceptable = acceptable
+	}
 }
 
-func NewGoogleBreaker() *GoogleBreaker {
+// WithSlowCallThreshold 设置慢调用阈值，耗时超过 threshold 的请求即使成功也计为失败，默认不检测
+func WithSlowCallThreshold(threshold time.Duration) GoogleBreakerOption {
+	return func(opt *googleBreakerOptions) {
+		opt.slowThreshold = threshold
+	}
+}
+
+// WithClock 指定时钟，测试时可以传入 clocktest.FakeClock
+func WithClock(c clock.Clock) GoogleBreakerOption {
+	return func(opt *googleBreakerOptions) {
+		opt.clock = c
+	}
+}
+
+func NewGoogleBreaker(opts ...GoogleBreakerOption) *GoogleBreaker {
+	o := googleBreakerOptions{
+		k:          k,
+		window:     window,
+		buckets:    buckets,
+		acceptable: defaultAcceptable,
+		clock:      clock.Default,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if len(o.name) == 0 {
+		o.name = stringx.Rand()
+	}
+	// 桶数量或桶间隔非法时使用默认值，避免除零或时间轮无法轮转
+	if o.buckets <= 0 {
+		o.buckets = buckets
+	}
+	if o.window/time.Duration(o.buckets) <= 0 {
+		o.window = window
+		o.buckets = buckets
+	}
+
 	return &GoogleBreaker{
-		k:             k,
-		rollingWindow: collection.NewRollingWindow(buckets, time.Duration(int64(window)/int64(buckets))),
+		name:          o.name,
+		k:             o.k,
+		minRequests:   o.minRequests,
+		acceptable:    o.acceptable,
+		slowThreshold: o.slowThreshold,
+		clock:         o.clock,
+		rollingWindow: collection.NewRollingWindow(o.buckets, time.Duration(int64(o.window)/int64(o.buckets)),
+			collection.WithClock(o.clock)),
 	}
 }
 
+// Name 熔断器名称
+func (b *GoogleBreaker) Name() string {
+	return b.name
+}
+
 // Do 传入请求，由熔断器判断是否执行
 func (b *GoogleBreaker) Do(req func() error) error {
+	return b.doReq(req, false)
+}
+
+// DoCtx 与 Do 类似，但会将 ctx 传给 req
+// ctx 已经结束时直接返回 ctx.Err()，不执行请求；req 返回 context.Canceled 时不计入统计
+func (b *GoogleBreaker) DoCtx(ctx context.Context, req func(ctx context.Context) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return b.doReq(func() error {
+		return req(ctx)
+	}, true)
+}
+
+// doReq 执行请求，ignoreCanceled 为 true 时调用方主动取消的请求不计入统计
+func (b *GoogleBreaker) doReq(req func() error, ignoreCanceled bool) error {
 	// 判断是否触发熔断
 	if err := b.accept(); err != nil {
+		b.emit(EventDrop, err.Error())
 		return err
 	}
 
-	// 执行调用
+	// 执行调用，同时测量耗时
+	start := b.clock.Now()
 	err := req()
-	if err != nil {
+	elapsed := b.clock.Since(start)
+	switch {
+	case ignoreCanceled && errors.Is(err, context.Canceled):
+		b.emit(EventIgnore, err.Error())
+	case !b.acceptable(err):
 		b.markFail() // 标记失败
-	} else {
+		reason := "unacceptable"
+		if err != nil {
+			reason = err.Error()
+		}
+		b.emit(EventReject, reason)
+	case b.slowThreshold > 0 && elapsed > b.slowThreshold:
+		b.markFail() // 慢调用，标记失败
+		b.emit(EventReject, fmt.Sprintf("slow call: %s exceeds %s", elapsed, b.slowThreshold))
+	default:
 		b.markSuccess() // 标记成功
+		b.emit(EventAccept, "")
 	}
 
 	return err
@@ -56,10 +204,20 @@ func (b *GoogleBreaker) Do(req func() error) error {
 func (b *GoogleBreaker) accept() error {
 	// 获取请求总数和请求成功次数
 	accepts, requests := b.history()
+	// 请求数不足时不丢弃请求
+	if requests < b.minRequests {
+		b.transit(StateClosed, accepts, requests)
+		return nil
+	}
 	// 计算请求丢弃概率
 	dropRatio := math.Max(0, (float64(requests)-b.k*float64(accepts))/(float64(requests)+1))
+	if dropRatio <= 0 {
+		b.transit(StateClosed, accepts, requests)
+		return nil
+	}
 	// 动态判断是否触发熔断
 	if rand.Float64() < dropRatio {
+		b.transit(StateOpen, accepts, requests)
 		return ErrBreakerOpen
 	}
 
@@ -87,3 +245,8 @@ func (b *GoogleBreaker) markSuccess() {
 func (b *GoogleBreaker) markFail() {
 	b.rollingWindow.Add(0)
 }
+
+// 默认的 Acceptable 函数，判断 err 是否为 nil
+func defaultAcceptable(err error) bool {
+	return err == nil
+}
@@ -0,0 +1,134 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go-zero-source/util/clock/clocktest"
+	"testing"
+	"time"
+)
+
+func TestGoogleBreakerRecover(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	b := NewGoogleBreaker(WithClock(clk))
+
+	errFail := errors.New("fail")
+	for i := 0; i < 1000; i++ {
+		_ = b.Do(func() error {
+			return errFail
+		})
+	}
+	accepts, requests := b.history()
+	assert.Equal(t, int64(0), accepts)
+	assert.True(t, requests > 0)
+
+	// 窗口整体滑过后，失败记录全部过期，熔断器恢复
+	clk.Advance(window)
+	_, requests = b.history()
+	assert.Equal(t, int64(0), requests)
+	assert.Nil(t, b.accept())
+}
+
+func TestGoogleBreakerMinRequests(t *testing.T) {
+	b := NewGoogleBreaker(WithMinRequests(10))
+
+	errFail := errors.New("fail")
+	// 请求数不足时失败也不会丢弃请求
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, errFail, b.Do(func() error {
+			return errFail
+		}))
+	}
+	_, requests := b.history()
+	assert.Equal(t, int64(10), requests)
+}
+
+func TestGoogleBreakerOptions(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	errIgnored := errors.New("ignored")
+	b := NewGoogleBreaker(WithK(2), WithWindow(time.Second), WithBuckets(10), WithClock(clk),
+		WithAcceptable(func(err error) bool {
+			return err == nil || err == errIgnored
+		}))
+	assert.Equal(t, float64(2), b.k)
+
+	// 可接受的错误计为成功
+	assert.Equal(t, errIgnored, b.Do(func() error {
+		return errIgnored
+	}))
+	accepts, requests := b.history()
+	assert.Equal(t, int64(1), accepts)
+	assert.Equal(t, int64(1), requests)
+
+	// 窗口长度 1s
+	clk.Advance(time.Second)
+	_, requests = b.history()
+	assert.Equal(t, int64(0), requests)
+}
+
+func TestGoogleBreakerInvalidWindow(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	// 非法的桶数量与窗口长度回退为默认值 10s / 40 个桶
+	for _, opts := range [][]GoogleBreakerOption{
+		{WithBuckets(0)},
+		{WithBuckets(-1)},
+		{WithWindow(time.Nanosecond)},
+		{WithWindow(time.Second), WithBuckets(int(time.Second) + 1)},
+	} {
+		b := NewGoogleBreaker(append(opts, WithClock(clk))...)
+		assert.NotPanics(t, func() {
+			assert.Nil(t, b.Do(func() error {
+				return nil
+			}))
+		})
+
+		clk.Advance(time.Second)
+		_, requests := b.history()
+		assert.Equal(t, int64(1), requests)
+		clk.Advance(window)
+		_, requests = b.history()
+		assert.Equal(t, int64(0), requests)
+	}
+}
+
+func TestGoogleBreakerDoCtx(t *testing.T) {
+	b := NewGoogleBreaker()
+
+	// ctx 已经结束时不执行请求
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, b.DoCtx(ctx, func(ctx context.Context) error {
+		t.Fatal("should not be called")
+		return nil
+	}))
+
+	// 调用方取消不计入统计，超时计为失败
+	assert.Equal(t, context.Canceled, b.DoCtx(context.Background(), func(ctx context.Context) error {
+		return context.Canceled
+	}))
+	assert.Equal(t, context.DeadlineExceeded, b.DoCtx(context.Background(), func(ctx context.Context) error {
+		return context.DeadlineExceeded
+	}))
+	accepts, requests := b.history()
+	assert.Equal(t, int64(0), accepts)
+	assert.Equal(t, int64(1), requests)
+}
+
+func TestGoogleBreakerSlowCall(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	b := NewGoogleBreaker(WithClock(clk), WithSlowCallThreshold(time.Second), WithMinRequests(10))
+
+	// 成功但超过阈值计为失败，未超过阈值计为成功
+	assert.Nil(t, b.Do(func() error {
+		clk.Advance(2 * time.Second)
+		return nil
+	}))
+	assert.Nil(t, b.Do(func() error {
+		clk.Advance(time.Second)
+		return nil
+	}))
+	accepts, requests := b.history()
+	assert.Equal(t, int64(1), accepts)
+	assert.Equal(t, int64(2), requests)
+}
@@ -1,6 +1,7 @@
 package breaker
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -45,6 +46,27 @@ type (
 
 		// DoWithFallbackAcceptable 方法与 Do 方法类似，但是同时多传了 fallback 和 acceptable 函数
 		DoWithFallbackAcceptable(req func() error, fallback func(err error) error, acceptable Acceptable) error
+
+		// DoCtx 方法与 Do 方法类似，但会将 ctx 传给 req
+		// ctx 已经结束时直接返回 ctx.Err()，不执行请求；req 返回 context.Canceled 时不计入统计
+		DoCtx(ctx context.Context, req func(ctx context.Context) error) error
+
+		// DoWithAcceptableCtx 是 DoWithAcceptable 的 context 版本
+		DoWithAcceptableCtx(ctx context.Context, req func(ctx context.Context) error, acceptable Acceptable) error
+
+		// DoWithFallbackCtx 是 DoWithFallback 的 context 版本
+		DoWithFallbackCtx(ctx context.Context, req func(ctx context.Context) error, fallback func(err error) error) error
+
+		// DoWithFallbackAcceptableCtx 是 DoWithFallbackAcceptable 的 context 版本
+		DoWithFallbackAcceptableCtx(ctx context.Context, req func(ctx context.Context) error,
+			fallback func(err error) error, acceptable Acceptable) error
+
+		// OnStateChange 注册状态变化回调，开始丢弃请求或恢复时同步调用
+		OnStateChange(fn StateChangeHandler)
+
+		// Subscribe 订阅请求的放行、失败、丢弃事件，订阅方消费过慢时丢弃事件
+		// ctx 结束时取消订阅并关闭通道
+		Subscribe(ctx context.Context) <-chan Event
 	}
 
 	// Option 定义了 Breaker 的自定义方法
@@ -67,18 +89,32 @@ type (
 	circuitBreaker struct {
 		name string
 		throttle
+		// 熔断算法，默认为 google 自适应算法
+		internal internalThrottle
+		notifier *notifier
+		// 错误归类策略，未传 Acceptable 时使用
+		classifiers []Classifier
+		classify    func(err error) Outcome
+		classifyCtx func(err error) Outcome
+		// 慢调用阈值，为 0 时不检测
+		slowCallThreshold time.Duration
 	}
 
 	//
 	internalThrottle interface {
 		allow() (internalPromise, error)
-		doReq(req func() error, fallback func(err error) error, acceptable Acceptable) error
+		// doReq 执行请求，classify 归类请求真实返回的错误 err，slowErr 不为 nil 时请求为慢调用
+		doReq(req func() error, fallback func(err error) error, classify func(err, slowErr error) Outcome) error
+		// setStateListener 设置状态变化回调，需要在释放内部锁之后调用
+		setStateListener(listener stateListener)
+		// setSlowCallThreshold 设置慢调用阈值，为 0 时不检测
+		setSlowCallThreshold(threshold time.Duration)
 	}
 
 	//
 	throttle interface {
 		allow() (Promise, error)
-		doReq(req func() error, fallback func(err error) error, acceptable Acceptable) error
+		doReq(req func() error, fallback func(err error) error, classify func(err error) Outcome) error
 	}
 )
 
@@ -93,8 +129,17 @@ func NewBreaker(opts ...Option) Breaker {
 		b.name = stringx.Rand()
 	}
 
-	// 初始化 google 算法
-	b.throttle = newLoggedThrottle(b.name, newGoogleBreaker())
+	// 默认使用 google 算法
+	if b.internal == nil {
+		b.internal = newGoogleBreaker()
+	}
+	b.notifier = newNotifier(b.name)
+	b.internal.setStateListener(b.notifier.stateChanged)
+	b.internal.setSlowCallThreshold(b.slowCallThreshold)
+	b.throttle = newLoggedThrottle(b.name, b.internal, b.notifier)
+	b.classify = classifyWith(b.classifiers)
+	// context 版本的方法默认忽略调用方的取消，排在自定义策略之后，允许被覆盖
+	b.classifyCtx = classifyWith(append(b.classifiers[:len(b.classifiers):len(b.classifiers)], ContextErrors()))
 
 	return &b
 }
@@ -104,26 +149,65 @@ func (cb *circuitBreaker) Allow() (Promise, error) {
 }
 
 func (cb *circuitBreaker) Do(req func() error) error {
-	return cb.throttle.doReq(req, nil, defaultAcceptable)
+	return cb.throttle.doReq(req, nil, cb.classify)
 }
 
 func (cb *circuitBreaker) DoWithAcceptable(req func() error, acceptable Acceptable) error {
-	return cb.throttle.doReq(req, nil, acceptable)
+	return cb.throttle.doReq(req, nil, classifyBy(acceptable))
 }
 
 func (cb *circuitBreaker) DoWithFallback(req func() error, fallback func(err error) error) error {
-	return cb.throttle.doReq(req, fallback, defaultAcceptable)
+	return cb.throttle.doReq(req, fallback, cb.classify)
 }
 
 func (cb *circuitBreaker) DoWithFallbackAcceptable(req func() error, fallback func(err error) error,
 	acceptable Acceptable) error {
-	return cb.throttle.doReq(req, fallback, acceptable)
+	return cb.throttle.doReq(req, fallback, classifyBy(acceptable))
+}
+
+func (cb *circuitBreaker) DoCtx(ctx context.Context, req func(ctx context.Context) error) error {
+	return cb.doCtx(ctx, req, nil, cb.classifyCtx)
+}
+
+func (cb *circuitBreaker) DoWithAcceptableCtx(ctx context.Context, req func(ctx context.Context) error,
+	acceptable Acceptable) error {
+	return cb.doCtx(ctx, req, nil, classifyCtx(acceptable))
+}
+
+func (cb *circuitBreaker) DoWithFallbackCtx(ctx context.Context, req func(ctx context.Context) error,
+	fallback func(err error) error) error {
+	return cb.doCtx(ctx, req, fallback, cb.classifyCtx)
+}
+
+func (cb *circuitBreaker) DoWithFallbackAcceptableCtx(ctx context.Context, req func(ctx context.Context) error,
+	fallback func(err error) error, acceptable Acceptable) error {
+	return cb.doCtx(ctx, req, fallback, classifyCtx(acceptable))
+}
+
+func (cb *circuitBreaker) doCtx(ctx context.Context, req func(ctx context.Context) error,
+	fallback func(err error) error, classify func(err error) Outcome) error {
+	// ctx 已经结束，请求注定失败，直接返回且不计入统计
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return cb.throttle.doReq(func() error {
+		return req(ctx)
+	}, fallback, classify)
 }
 
 func (cb *circuitBreaker) Name() string {
 	return cb.name
 }
 
+func (cb *circuitBreaker) OnStateChange(fn StateChangeHandler) {
+	cb.notifier.onStateChange(fn)
+}
+
+func (cb *circuitBreaker) Subscribe(ctx context.Context) <-chan Event {
+	return cb.notifier.subscribe(ctx)
+}
+
 // WithName returns a function to set the name of a Breaker.
 func WithName(name string) Option {
 	return func(b *circuitBreaker) {
@@ -136,37 +220,102 @@ func defaultAcceptable(err error) bool {
 	return err == nil
 }
 
+// classifyBy 根据 acceptable 将请求结果分为成功和失败
+func classifyBy(acceptable Acceptable) func(err error) Outcome {
+	return func(err error) Outcome {
+		if acceptable(err) {
+			return Success
+		}
+
+		return Failure
+	}
+}
+
+// classifyCtx 与 classifyBy 类似，但调用方主动取消的请求不代表下游出错，不计入统计
+func classifyCtx(acceptable Acceptable) func(err error) Outcome {
+	classify := classifyBy(acceptable)
+	return func(err error) Outcome {
+		if errors.Is(err, context.Canceled) {
+			return Ignored
+		}
+
+		return classify(err)
+	}
+}
+
 type loggedThrottle struct {
 	name string
 	internalThrottle
-	errWin *errorWindow
+	errWin   *errorWindow
+	notifier *notifier
 }
 
-func newLoggedThrottle(name string, t internalThrottle) loggedThrottle {
+func newLoggedThrottle(name string, t internalThrottle, n *notifier) loggedThrottle {
 	return loggedThrottle{
 		name:             name,
 		internalThrottle: t,
 		errWin:           new(errorWindow),
+		notifier:         n,
 	}
 }
 
 // 判断是否触发熔断
 func (lt loggedThrottle) allow() (Promise, error) {
 	promise, err := lt.internalThrottle.allow()
+	if err != nil {
+		lt.notifier.emit(EventDrop, err.Error())
+	}
 	return promiseWithReason{
-		promise: promise,
-		errWin:  lt.errWin,
+		promise:  promise,
+		errWin:   lt.errWin,
+		notifier: lt.notifier,
 	}, lt.logError(err)
 }
 
-func (lt loggedThrottle) doReq(req func() error, fallback func(err error) error, acceptable Acceptable) error {
-	return lt.logError(lt.internalThrottle.doReq(req, fallback, func(err error) bool {
-		accept := acceptable(err)
-		if !accept && err != nil {
-			lt.errWin.add(err.Error())
+func (lt loggedThrottle) doReq(req func() error, fallback func(err error) error,
+	classify func(err error) Outcome) error {
+	// 内部算法丢弃请求时，会调用 fallback 或直接返回 ErrServiceUnavailable
+	var dropped bool
+	if fallback != nil {
+		fb := fallback
+		fallback = func(err error) error {
+			dropped = true
+			lt.notifier.emit(EventDrop, err.Error())
+			return fb(err)
+		}
+	}
+
+	var called bool
+	err := lt.internalThrottle.doReq(req, fallback, func(err, slowErr error) Outcome {
+		called = true
+		// 先按调用方的策略归类真实的错误，再单独应用慢调用规则：成功的慢调用计为失败
+		result := classify(err)
+		cause := err
+		if result == Success && slowErr != nil {
+			result, cause = Failure, slowErr
+		}
+
+		switch result {
+		case Success:
+			lt.notifier.emit(EventAccept, "")
+		case Ignored:
+			lt.notifier.emit(EventIgnore, err.Error())
+		default:
+			reason := "unacceptable"
+			if cause != nil {
+				reason = cause.Error()
+				lt.errWin.add(reason)
+			}
+			lt.notifier.emit(EventReject, reason)
 		}
-		return accept
-	}))
+		return result
+	})
+	// req 本身也可能返回 ErrServiceUnavailable，只有未执行 req 时才是被丢弃
+	if !called && !dropped && errors.Is(err, ErrServiceUnavailable) {
+		lt.notifier.emit(EventDrop, err.Error())
+	}
+
+	return lt.logError(err)
 }
 
 func (lt loggedThrottle) logError(err error) error {
@@ -210,15 +359,18 @@ func (ew *errorWindow) String() string {
 }
 
 type promiseWithReason struct {
-	promise internalPromise
-	errWin  *errorWindow
+	promise  internalPromise
+	errWin   *errorWindow
+	notifier *notifier
 }
 
 func (p promiseWithReason) Accept() {
 	p.promise.Accept()
+	p.notifier.emit(EventAccept, "")
 }
 
 func (p promiseWithReason) Reject(reason string) {
 	p.errWin.add(reason)
 	p.promise.Reject()
+	p.notifier.emit(EventReject, reason)
 }
@@ -0,0 +1,80 @@
+package breaker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"go-zero-source/util/clock/clocktest"
+)
+
+func TestDoCtxDone(t *testing.T) {
+	b := NewBreaker()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// ctx 已经结束时不执行请求，也不计入统计
+	var called bool
+	err := b.DoCtx(ctx, func(ctx context.Context) error {
+		called = true
+		return nil
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.False(t, called)
+	assert.Equal(t, Counts{}, b.(*circuitBreaker).notifier.counts())
+}
+
+func TestNopBreakerDoCtxDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// 与真实的熔断器一致，ctx 已经结束时不执行请求
+	var called bool
+	err := newNopBreaker().DoWithFallbackCtx(ctx, func(ctx context.Context) error {
+		called = true
+		return nil
+	}, nil)
+	assert.Equal(t, context.Canceled, err)
+	assert.False(t, called)
+}
+
+func TestDoCtxCanceled(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	b := NewBreaker(WithThreeState(WithClock(clk), WithTripPolicy(ConsecutiveFailures(1))))
+
+	// 调用方取消不计为失败
+	canceled := func(ctx context.Context) error {
+		return context.Canceled
+	}
+	assert.Equal(t, context.Canceled, b.DoCtx(context.Background(), canceled))
+	assert.Nil(t, b.DoCtx(context.Background(), func(ctx context.Context) error {
+		return nil
+	}))
+	assert.Equal(t, Counts{Accepts: 1, Ignores: 1}, b.(*circuitBreaker).notifier.counts())
+
+	// 超时仍然计为失败
+	assert.Equal(t, context.DeadlineExceeded, b.DoCtx(context.Background(), func(ctx context.Context) error {
+		return context.DeadlineExceeded
+	}))
+	assert.Equal(t, ErrServiceUnavailable, b.Do(succeed))
+
+	// 半开状态下被取消的探测请求归还名额
+	clk.Advance(defaultOpenDuration)
+	assert.Equal(t, context.Canceled, b.DoCtx(context.Background(), canceled))
+	assert.Nil(t, b.Do(succeed))
+}
+
+func TestDoWithFallbackCtx(t *testing.T) {
+	b := NewBreaker(WithThreeState(WithTripPolicy(ConsecutiveFailures(1))))
+	assert.Equal(t, errTest, b.Do(fail))
+
+	err := b.DoWithFallbackCtx(context.Background(), func(ctx context.Context) error {
+		return nil
+	}, func(err error) error {
+		assert.Equal(t, ErrServiceUnavailable, err)
+		return errTest
+	})
+	assert.Equal(t, errTest, err)
+}
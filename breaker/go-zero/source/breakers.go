@@ -1,6 +1,9 @@
 package breaker
 
-import "sync"
+import (
+	"context"
+	"sync"
+)
 
 var (
 	lock sync.RWMutex
@@ -37,6 +40,37 @@ func DoWithFallbackAcceptable(name string, req func() error, fallback func(err e
 	})
 }
 
+// DoCtx calls Breaker.DoCtx on the Breaker with given name.
+func DoCtx(ctx context.Context, name string, req func(ctx context.Context) error) error {
+	return do(name, func(b Breaker) error {
+		return b.DoCtx(ctx, req)
+	})
+}
+
+// DoWithAcceptableCtx calls Breaker.DoWithAcceptableCtx on the Breaker with given name.
+func DoWithAcceptableCtx(ctx context.Context, name string, req func(ctx context.Context) error,
+	acceptable Acceptable) error {
+	return do(name, func(b Breaker) error {
+		return b.DoWithAcceptableCtx(ctx, req, acceptable)
+	})
+}
+
+// DoWithFallbackCtx calls Breaker.DoWithFallbackCtx on the Breaker with given name.
+func DoWithFallbackCtx(ctx context.Context, name string, req func(ctx context.Context) error,
+	fallback func(err error) error) error {
+	return do(name, func(b Breaker) error {
+		return b.DoWithFallbackCtx(ctx, req, fallback)
+	})
+}
+
+// DoWithFallbackAcceptableCtx calls Breaker.DoWithFallbackAcceptableCtx on the Breaker with given name.
+func DoWithFallbackAcceptableCtx(ctx context.Context, name string, req func(ctx context.Context) error,
+	fallback func(err error) error, acceptable Acceptable) error {
+	return do(name, func(b Breaker) error {
+		return b.DoWithFallbackAcceptableCtx(ctx, req, fallback, acceptable)
+	})
+}
+
 // GetBreaker returns the Breaker with the given name.
 func GetBreaker(name string) Breaker {
 	lock.RLock()
@@ -64,6 +98,35 @@ func NoBreakerFor(name string) {
 	lock.Unlock()
 }
 
+// Range 遍历注册表中的全部熔断器及其累计请求情况，fn 返回 false 时停止遍历
+func Range(fn func(name string, b Breaker, counts Counts) bool) {
+	lock.RLock()
+	snapshot := make(map[string]Breaker, len(breakers))
+	for name, b := range breakers {
+		snapshot[name] = b
+	}
+	lock.RUnlock()
+
+	for name, b := range snapshot {
+		var counts Counts
+		if cb, ok := b.(*circuitBreaker); ok {
+			counts = cb.notifier.counts()
+		}
+		if !fn(name, b, counts) {
+			return
+		}
+	}
+}
+
+// DropRatio 熔断器统计窗口内被丢弃的请求比例，没有请求或不是 NewBreaker 创建的熔断器时返回 0
+func DropRatio(b Breaker) float64 {
+	if cb, ok := b.(*circuitBreaker); ok {
+		return cb.notifier.dropRatio()
+	}
+
+	return 0
+}
+
 func do(name string, execute func(b Breaker) error) error {
 	return execute(GetBreaker(name))
 }
@@ -0,0 +1,209 @@
+package breaker
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/collection"
+)
+
+// 订阅通道的缓冲大小，订阅方消费过慢时丢弃事件，不阻塞请求
+const eventBufferSize = 128
+
+// 熔断器事件类型
+const (
+	// EventAccept 请求被放行且成功
+	EventAccept EventType = iota
+	// EventReject 请求被放行但失败
+	EventReject
+	// EventDrop 请求被熔断器丢弃
+	EventDrop
+	// EventIgnore 请求被放行，但结果不计入统计，例如调用方主动取消
+	EventIgnore
+)
+
+type (
+	// EventType 熔断器事件类型
+	EventType int
+
+	// Event 熔断器事件
+	Event struct {
+		Name   string    // 熔断器名称
+		Type   EventType // 事件类型
+		Reason string    // 失败、丢弃或忽略的原因
+		Time   time.Time
+	}
+
+	// Counts 熔断器自创建以来的累计请求情况
+	Counts struct {
+		Accepts int64 // 放行且成功的请求数
+		Rejects int64 // 放行但失败的请求数
+		Drops   int64 // 被丢弃的请求数
+		Ignores int64 // 放行但不计入统计的请求数
+	}
+
+	// StateChangeHandler 熔断器状态变化时的回调
+	StateChangeHandler func(name string, from, to State, stats Stats)
+
+	// notifier 管理状态变化回调与事件订阅
+	notifier struct {
+		name        string
+		lock        sync.RWMutex
+		handlers    []StateChangeHandler
+		subscribers []chan Event
+		// 是否有订阅方，没有订阅时跳过事件的构造
+		subscribed int32
+		// 累计的请求情况，按事件类型计数
+		accepts int64
+		rejects int64
+		drops   int64
+		ignores int64
+		// 统计窗口内的请求情况，Sum 为丢弃数，Count 为请求总数
+		window *collection.RollingWindow
+	}
+)
+
+// Requests 请求总数
+func (c Counts) Requests() int64 {
+	return c.Accepts + c.Rejects + c.Drops + c.Ignores
+}
+
+func (t EventType) String() string {
+	switch t {
+	case EventAccept:
+		return "accept"
+	case EventReject:
+		return "reject"
+	case EventDrop:
+		return "drop"
+	case EventIgnore:
+		return "ignore"
+	default:
+		return "unknown"
+	}
+}
+
+func newNotifier(name string) *notifier {
+	return &notifier{
+		name:   name,
+		window: collection.NewRollingWindow(buckets, time.Duration(int64(window)/int64(buckets))),
+	}
+}
+
+func (n *notifier) onStateChange(fn StateChangeHandler) {
+	n.lock.Lock()
+	n.handlers = append(n.handlers, fn)
+	n.lock.Unlock()
+}
+
+// subscribe 添加订阅，ctx 结束时取消订阅并关闭通道
+func (n *notifier) subscribe(ctx context.Context) <-chan Event {
+	ch := make(chan Event, eventBufferSize)
+
+	n.lock.Lock()
+	n.subscribers = append(n.subscribers, ch)
+	atomic.StoreInt32(&n.subscribed, 1)
+	n.lock.Unlock()
+
+	// ctx 永远不会结束时无需等待，避免泄漏 goroutine
+	if ctx.Done() != nil {
+		go func() {
+			<-ctx.Done()
+			n.unsubscribe(ch)
+		}()
+	}
+
+	return ch
+}
+
+// unsubscribe 移除订阅并关闭通道，持有写锁时关闭，emit 不会向已关闭的通道发送
+func (n *notifier) unsubscribe(ch chan Event) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	for i, sub := range n.subscribers {
+		if sub == ch {
+			n.subscribers = append(n.subscribers[:i], n.subscribers[i+1:]...)
+			close(ch)
+			break
+		}
+	}
+	if len(n.subscribers) == 0 {
+		atomic.StoreInt32(&n.subscribed, 0)
+	}
+}
+
+// stateChanged 同步调用全部状态变化回调
+func (n *notifier) stateChanged(from, to State, stats Stats) {
+	n.lock.RLock()
+	handlers := n.handlers
+	n.lock.RUnlock()
+
+	for _, handler := range handlers {
+		handler(n.name, from, to, stats)
+	}
+}
+
+func (n *notifier) counts() Counts {
+	return Counts{
+		Accepts: atomic.LoadInt64(&n.accepts),
+		Rejects: atomic.LoadInt64(&n.rejects),
+		Drops:   atomic.LoadInt64(&n.drops),
+		Ignores: atomic.LoadInt64(&n.ignores),
+	}
+}
+
+// dropRatio 统计窗口内被丢弃的请求比例，没有请求时为 0
+func (n *notifier) dropRatio() float64 {
+	var drops, requests int64
+	n.window.Reduce(func(b *collection.Bucket) {
+		drops += int64(b.Sum)
+		requests += b.Count
+	})
+	if requests == 0 {
+		return 0
+	}
+
+	return float64(drops) / float64(requests)
+}
+
+// emit 累计请求情况，并向全部订阅方发送事件，通道已满时丢弃
+func (n *notifier) emit(typ EventType, reason string) {
+	switch typ {
+	case EventAccept:
+		atomic.AddInt64(&n.accepts, 1)
+	case EventReject:
+		atomic.AddInt64(&n.rejects, 1)
+	case EventDrop:
+		atomic.AddInt64(&n.drops, 1)
+	case EventIgnore:
+		atomic.AddInt64(&n.ignores, 1)
+	}
+	if typ == EventDrop {
+		n.window.Add(1)
+	} else {
+		n.window.Add(0)
+	}
+
+	if atomic.LoadInt32(&n.subscribed) == 0 {
+		return
+	}
+
+	event := Event{
+		Name:   n.name,
+		Type:   typ,
+		Reason: reason,
+		Time:   time.Now(),
+	}
+
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+	for _, ch := range n.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
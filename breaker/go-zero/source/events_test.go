@@ -0,0 +1,117 @@
+package breaker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"go-zero-source/util/clock/clocktest"
+)
+
+type transition struct {
+	from, to State
+	stats    Stats
+}
+
+func TestThreeStateBreakerOnStateChange(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	b := NewBreaker(WithName("dep"), WithThreeState(WithClock(clk), WithTripPolicy(ConsecutiveFailures(2))))
+
+	var transitions []transition
+	b.OnStateChange(func(name string, from, to State, stats Stats) {
+		assert.Equal(t, "dep", name)
+		// 回调在锁外执行，可以安全地调用熔断器
+		_, _ = b.Allow()
+		transitions = append(transitions, transition{from: from, to: to, stats: stats})
+	})
+
+	_ = b.Do(fail)
+	_ = b.Do(fail)
+	clk.Advance(defaultOpenDuration)
+	_ = b.Do(succeed)
+
+	assert.Equal(t, []transition{
+		{from: StateClosed, to: StateOpen, stats: Stats{Requests: 2, Failures: 2, ConsecutiveFailures: 2, SlowCallRequests: 2}},
+		{from: StateOpen, to: StateHalfOpen, stats: Stats{Requests: 2, Failures: 2, ConsecutiveFailures: 2, SlowCallRequests: 2}},
+		{from: StateHalfOpen, to: StateClosed, stats: Stats{Requests: 2, Failures: 2, ConsecutiveFailures: 2, SlowCallRequests: 2}},
+	}, transitions[:3])
+}
+
+func TestGoogleBreakerOnStateChange(t *testing.T) {
+	b := NewBreaker()
+	var transitions []transition
+	b.OnStateChange(func(name string, from, to State, stats Stats) {
+		transitions = append(transitions, transition{from: from, to: to, stats: stats})
+	})
+
+	// 持续失败，直到开始丢弃请求
+	for i := 0; i < 1000 && len(transitions) == 0; i++ {
+		_ = b.Do(fail)
+	}
+	assert.Equal(t, 1, len(transitions))
+	assert.Equal(t, StateClosed, transitions[0].from)
+	assert.Equal(t, StateOpen, transitions[0].to)
+	assert.Equal(t, transitions[0].stats.Requests, transitions[0].stats.Failures)
+}
+
+func TestSubscribe(t *testing.T) {
+	b := NewBreaker(WithName("dep"), WithThreeState(WithTripPolicy(ConsecutiveFailures(2))))
+	events := b.Subscribe(context.Background())
+
+	assert.Nil(t, b.Do(succeed))
+	p, err := b.Allow()
+	assert.Nil(t, err)
+	p.Reject("bad")
+	assert.Equal(t, errTest, b.Do(fail))
+	assert.Equal(t, ErrServiceUnavailable, b.Do(succeed))
+	assert.Nil(t, b.DoWithFallback(succeed, func(err error) error {
+		return nil
+	}))
+
+	var types []EventType
+	var reasons []string
+	for i := 0; i < 5; i++ {
+		e := <-events
+		assert.Equal(t, "dep", e.Name)
+		types = append(types, e.Type)
+		reasons = append(reasons, e.Reason)
+	}
+	assert.Equal(t, []EventType{EventAccept, EventReject, EventReject, EventDrop, EventDrop}, types)
+	assert.Equal(t, []string{"", "bad", errTest.Error(), ErrServiceUnavailable.Error(),
+		ErrServiceUnavailable.Error()}, reasons)
+
+	// nopBreaker 不会产生事件，返回的通道已关闭
+	_, ok := <-newNopBreaker().Subscribe(context.Background())
+	assert.False(t, ok)
+}
+
+func TestUnsubscribe(t *testing.T) {
+	b := NewBreaker()
+	ctx, cancel := context.WithCancel(context.Background())
+	events := b.Subscribe(ctx)
+
+	// 取消后通道被关闭，最后一个订阅方取消后不再构造事件
+	cancel()
+	for range events {
+	}
+	assert.Nil(t, b.Do(succeed))
+	cb := b.(*circuitBreaker)
+	assert.Empty(t, cb.notifier.subscribers)
+	assert.Equal(t, int32(0), cb.notifier.subscribed)
+}
+
+func TestDropRatio(t *testing.T) {
+	b := NewBreaker(WithThreeState(WithTripPolicy(ConsecutiveFailures(1))))
+	assert.Equal(t, float64(0), DropRatio(b))
+
+	assert.Equal(t, errTest, b.Do(fail))
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, ErrServiceUnavailable, b.Do(succeed))
+	}
+	assert.Equal(t, 0.75, DropRatio(b))
+
+	// nopBreaker 没有统计
+	assert.Equal(t, float64(0), DropRatio(newNopBreaker()))
+}
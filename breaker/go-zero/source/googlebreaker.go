@@ -2,10 +2,13 @@ package breaker
 
 import (
 	"math"
+	"sync/atomic"
 	"time"
 
 	"github.com/zeromicro/go-zero/core/collection"
 	"github.com/zeromicro/go-zero/core/mathx"
+
+	"go-zero-source/util/clock"
 )
 
 const (
@@ -22,6 +25,10 @@ type googleBreaker struct {
 	k     float64                   // google 算法中的倍率，默认 1.5
 	stat  *collection.RollingWindow // 时间轮
 	proba *mathx.Proba              //  动态概率
+	// 当前状态，开始丢弃请求时为打开，丢弃概率回落到 0 时为关闭
+	state    int32
+	listener stateListener
+	slowCall slowCall // 慢调用检测
 }
 
 // 创建一个 google 自适应算法的熔断器
@@ -46,17 +53,40 @@ func (b *googleBreaker) accept() error {
 	// https://landing.google.com/sre/sre-book/chapters/handling-overload/#eq2101
 	dropRatio := math.Max(0, (float64(total-protection)-weightedAccepts)/float64(total+1))
 	if dropRatio <= 0 {
+		b.transit(StateClosed, accepts, total)
 		return nil
 	}
 
 	// 动态判断是否触发熔断
 	if b.proba.TrueOnProba(dropRatio) {
+		b.transit(StateOpen, accepts, total)
 		return ErrServiceUnavailable
 	}
 
 	return nil
 }
 
+func (b *googleBreaker) setStateListener(listener stateListener) {
+	b.listener = listener
+}
+
+func (b *googleBreaker) setSlowCallThreshold(threshold time.Duration) {
+	b.slowCall = newSlowCall(threshold, clock.Default)
+}
+
+// transit 切换状态，状态变化时回调
+func (b *googleBreaker) transit(to State, accepts, total int64) {
+	from := State(atomic.SwapInt32(&b.state, int32(to)))
+	if from == to || b.listener == nil {
+		return
+	}
+
+	b.listener(from, to, Stats{
+		Requests: total,
+		Failures: total - accepts,
+	})
+}
+
 func (b *googleBreaker) allow() (internalPromise, error) {
 	// 判断是否触发熔断
 	if err := b.accept(); err != nil {
@@ -68,7 +98,8 @@ func (b *googleBreaker) allow() (internalPromise, error) {
 	}, nil
 }
 
-func (b *googleBreaker) doReq(req func() error, fallback func(err error) error, acceptable Acceptable) error {
+func (b *googleBreaker) doReq(req func() error, fallback func(err error) error,
+	classify func(err, slowErr error) Outcome) error {
 	// 判断是否触发熔断
 	if err := b.accept(); err != nil {
 		// 如果传了 fallback 函数，则执行 fallback 函数
@@ -87,12 +118,14 @@ func (b *googleBreaker) doReq(req func() error, fallback func(err error) error,
 		}
 	}()
 
-	// 执行真正的调用
+	// 执行真正的调用，同时测量耗时，归类真实的错误后再应用慢调用规则
+	start := b.slowCall.start()
 	err := req()
-	// 如果接受，则标记成功
-	if acceptable(err) {
+	switch classify(err, b.slowCall.judge(start)) {
+	case Success:
+		// 如果接受，则标记成功
 		b.markSuccess()
-	} else {
+	case Failure:
 		// 否则标记失败
 		b.markFailure()
 	}
@@ -1,5 +1,7 @@
 package breaker
 
+import "context"
+
 const nopBreakerName = "nopBreaker"
 
 type nopBreaker struct{}
@@ -33,6 +35,44 @@ func (b nopBreaker) DoWithFallbackAcceptable(req func() error, _ func(err error)
 	return req()
 }
 
+func (b nopBreaker) DoCtx(ctx context.Context, req func(ctx context.Context) error) error {
+	return nopDoCtx(ctx, req)
+}
+
+func (b nopBreaker) DoWithAcceptableCtx(ctx context.Context, req func(ctx context.Context) error,
+	_ Acceptable) error {
+	return nopDoCtx(ctx, req)
+}
+
+func (b nopBreaker) DoWithFallbackCtx(ctx context.Context, req func(ctx context.Context) error,
+	_ func(err error) error) error {
+	return nopDoCtx(ctx, req)
+}
+
+func (b nopBreaker) DoWithFallbackAcceptableCtx(ctx context.Context, req func(ctx context.Context) error,
+	_ func(err error) error, _ Acceptable) error {
+	return nopDoCtx(ctx, req)
+}
+
+func (b nopBreaker) OnStateChange(_ StateChangeHandler) {
+}
+
+// Subscribe 不会产生任何事件，返回已关闭的通道，遍历通道时立即结束
+func (b nopBreaker) Subscribe(_ context.Context) <-chan Event {
+	ch := make(chan Event)
+	close(ch)
+	return ch
+}
+
+// nopDoCtx 与真实的熔断器一致，ctx 已经结束时直接返回 ctx.Err()，不执行请求
+func nopDoCtx(ctx context.Context, req func(ctx context.Context) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return req(ctx)
+}
+
 type nopPromise struct{}
 
 func (p nopPromise) Accept() {
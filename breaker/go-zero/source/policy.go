@@ -0,0 +1,119 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+// 请求结果对熔断器统计的影响
+const (
+	// Failure 计为失败
+	Failure Outcome = iota
+	// Success 计为成功
+	Success
+	// Ignored 中性结果，不计入统计，例如调用方主动取消、参数错误等业务错误
+	Ignored
+)
+
+type (
+	// Outcome 请求结果对熔断器统计的影响
+	Outcome int
+
+	// Classifier 将错误归类，ok 为 false 表示无法判断，交给下一个 Classifier
+	Classifier func(err error) (outcome Outcome, ok bool)
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case Failure:
+		return "failure"
+	case Success:
+		return "success"
+	case Ignored:
+		return "ignored"
+	default:
+		return "unknown"
+	}
+}
+
+// WithClassifiers 设置熔断器的错误归类策略，创建熔断器时配置一次，调用时无需再传 Acceptable
+// 请求成功（err == nil）总是计为成功；否则依次使用 classifiers 归类，都无法判断时计为失败
+// 调用时显式传入的 Acceptable 优先于该策略
+func WithClassifiers(classifiers ...Classifier) Option {
+	return func(b *circuitBreaker) {
+		b.classifiers = append(b.classifiers, classifiers...)
+	}
+}
+
+// ErrorsIs 错误链中包含 targets 任意一个时（errors.Is），归类为 outcome
+func ErrorsIs(outcome Outcome, targets ...error) Classifier {
+	return func(err error) (Outcome, bool) {
+		for _, target := range targets {
+			if errors.Is(err, target) {
+				return outcome, true
+			}
+		}
+
+		return 0, false
+	}
+}
+
+// ErrorsAs 错误链中包含 T 类型的错误时（errors.As），归类为 outcome
+func ErrorsAs[T error](outcome Outcome) Classifier {
+	return func(err error) (Outcome, bool) {
+		var target T
+		if errors.As(err, &target) {
+			return outcome, true
+		}
+
+		return 0, false
+	}
+}
+
+// Match match 返回 true 时归类为 outcome，可以用于按错误码归类，例如 5xx 计为失败、4xx 忽略
+func Match(outcome Outcome, match func(err error) bool) Classifier {
+	return func(err error) (Outcome, bool) {
+		if match(err) {
+			return outcome, true
+		}
+
+		return 0, false
+	}
+}
+
+// NetErrors 网络错误（包括超时）计为失败
+func NetErrors() Classifier {
+	return ErrorsAs[net.Error](Failure)
+}
+
+// ContextErrors 调用方主动取消时忽略，超时计为失败
+func ContextErrors() Classifier {
+	return func(err error) (Outcome, bool) {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return Ignored, true
+		case errors.Is(err, context.DeadlineExceeded):
+			return Failure, true
+		default:
+			return 0, false
+		}
+	}
+}
+
+// classifyWith 依次使用 classifiers 归类，都无法判断时计为失败
+func classifyWith(classifiers []Classifier) func(err error) Outcome {
+	return func(err error) Outcome {
+		if err == nil {
+			return Success
+		}
+
+		for _, classify := range classifiers {
+			if outcome, ok := classify(err); ok {
+				return outcome
+			}
+		}
+
+		return Failure
+	}
+}
@@ -0,0 +1,79 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type statusError struct {
+	code int
+}
+
+func (e statusError) Error() string {
+	return fmt.Sprintf("status %d", e.code)
+}
+
+func TestClassifyWith(t *testing.T) {
+	errBusiness := errors.New("business")
+	classify := classifyWith([]Classifier{
+		ErrorsIs(Ignored, errBusiness),
+		Match(Ignored, func(err error) bool {
+			var se statusError
+			return errors.As(err, &se) && se.code < 500
+		}),
+		ErrorsAs[statusError](Failure),
+		NetErrors(),
+		ContextErrors(),
+	})
+
+	tests := []struct {
+		err  error
+		want Outcome
+	}{
+		{nil, Success},
+		{errBusiness, Ignored},
+		{fmt.Errorf("wrapped: %w", errBusiness), Ignored},
+		{statusError{code: 404}, Ignored},
+		{statusError{code: 503}, Failure},
+		{&net.OpError{Op: "dial", Err: errors.New("refused")}, Failure},
+		{context.Canceled, Ignored},
+		{context.DeadlineExceeded, Failure},
+		{errors.New("unknown"), Failure},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, classify(test.err), fmt.Sprint(test.err))
+	}
+}
+
+func TestWithClassifiers(t *testing.T) {
+	errBusiness := errors.New("business")
+	b := NewBreaker(WithClassifiers(ErrorsIs(Ignored, errBusiness)),
+		WithThreeState(WithTripPolicy(ConsecutiveFailures(1))))
+
+	// 业务错误不计入统计
+	assert.Equal(t, errBusiness, b.Do(func() error {
+		return errBusiness
+	}))
+	assert.Equal(t, Counts{Ignores: 1}, b.(*circuitBreaker).notifier.counts())
+
+	// 显式传入的 Acceptable 优先于熔断器的策略
+	assert.Equal(t, errBusiness, b.DoWithAcceptable(func() error {
+		return errBusiness
+	}, defaultAcceptable))
+	assert.Equal(t, ErrServiceUnavailable, b.Do(succeed))
+}
+
+func TestWithClassifiersCtx(t *testing.T) {
+	// 自定义策略可以覆盖 context 版本默认忽略取消的行为
+	b := NewBreaker(WithClassifiers(ErrorsIs(Failure, context.Canceled)),
+		WithThreeState(WithTripPolicy(ConsecutiveFailures(1))))
+	assert.Equal(t, context.Canceled, b.DoCtx(context.Background(), func(ctx context.Context) error {
+		return context.Canceled
+	}))
+	assert.Equal(t, ErrServiceUnavailable, b.Do(succeed))
+}
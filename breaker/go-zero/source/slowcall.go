@@ -0,0 +1,76 @@
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go-zero-source/util/clock"
+)
+
+// ErrSlowCall 请求成功但耗时超过阈值时计为失败，失败原因匹配该错误
+// 慢调用规则在归类策略之后单独应用，归类策略只会收到请求真实返回的错误
+var ErrSlowCall = errors.New("slow call")
+
+type (
+	// slowCall 慢调用检测，在内部算法的 doReq 中测量请求耗时
+	slowCall struct {
+		threshold time.Duration // 慢调用阈值，为 0 时不检测
+		clock     clock.Clock
+	}
+
+	// slowCallError 慢调用错误，只用于记录失败原因，不会交给归类策略，也不会返回给调用方
+	slowCallError struct {
+		elapsed   time.Duration
+		threshold time.Duration
+	}
+)
+
+// WithSlowCallThreshold 设置慢调用阈值，耗时超过 threshold 的请求计为失败
+// 三态熔断器可以配合 SlowCallRatio 熔断策略，按慢调用比例熔断
+func WithSlowCallThreshold(threshold time.Duration) Option {
+	return func(b *circuitBreaker) {
+		b.slowCallThreshold = threshold
+	}
+}
+
+func newSlowCall(threshold time.Duration, c clock.Clock) slowCall {
+	return slowCall{
+		threshold: threshold,
+		clock:     c,
+	}
+}
+
+// start 请求开始时间
+func (s slowCall) start() time.Time {
+	if s.threshold <= 0 {
+		return time.Time{}
+	}
+
+	return s.clock.Now()
+}
+
+// judge 判断请求是否为慢调用，耗时超过阈值时返回 slowCallError，否则返回 nil
+func (s slowCall) judge(start time.Time) error {
+	if s.threshold <= 0 {
+		return nil
+	}
+
+	elapsed := s.clock.Since(start)
+	if elapsed <= s.threshold {
+		return nil
+	}
+
+	return slowCallError{
+		elapsed:   elapsed,
+		threshold: s.threshold,
+	}
+}
+
+func (e slowCallError) Error() string {
+	return fmt.Sprintf("slow call: %s exceeds %s", e.elapsed, e.threshold)
+}
+
+func (e slowCallError) Is(target error) bool {
+	return target == ErrSlowCall
+}
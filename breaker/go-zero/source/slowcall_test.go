@@ -0,0 +1,134 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"go-zero-source/util/clock/clocktest"
+)
+
+func TestSlowCall(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	b := NewBreaker(WithSlowCallThreshold(time.Second),
+		WithThreeState(WithClock(clk), WithTripPolicy(ConsecutiveFailures(2))))
+	events := b.Subscribe(context.Background())
+	slow := func() error {
+		clk.Advance(2 * time.Second)
+		return nil
+	}
+
+	// 慢调用的结果原样返回，但计为失败
+	assert.Nil(t, b.Do(slow))
+	event := <-events
+	assert.Equal(t, EventReject, event.Type)
+	assert.True(t, strings.HasPrefix(event.Reason, "slow call"))
+
+	// 刚好等于阈值不算慢调用
+	assert.Nil(t, b.Do(func() error {
+		clk.Advance(time.Second)
+		return nil
+	}))
+	assert.Equal(t, EventAccept, (<-events).Type)
+
+	assert.Nil(t, b.Do(slow))
+	assert.Nil(t, b.Do(slow))
+	assert.Equal(t, ErrServiceUnavailable, b.Do(succeed))
+}
+
+func TestSlowCallClassifier(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	errBusiness := errors.New("business")
+	var seen []error
+	b := NewBreaker(WithSlowCallThreshold(time.Second),
+		WithClassifiers(func(err error) (Outcome, bool) {
+			seen = append(seen, err)
+			return ErrorsIs(Ignored, errBusiness)(err)
+		}),
+		WithThreeState(WithClock(clk), WithTripPolicy(ConsecutiveFailures(2))))
+
+	// 归类策略只会收到真实的错误，成功的慢调用之后再计为失败
+	assert.Nil(t, b.Do(func() error {
+		clk.Advance(2 * time.Second)
+		return nil
+	}))
+	// 被忽略的错误即使是慢调用也保持忽略
+	assert.Equal(t, errBusiness, b.Do(func() error {
+		clk.Advance(2 * time.Second)
+		return errBusiness
+	}))
+	// 成功的请求不经过归类策略，慢调用错误也不会交给归类策略
+	assert.Equal(t, []error{errBusiness}, seen)
+	assert.Equal(t, Counts{Rejects: 1, Ignores: 1}, b.(*circuitBreaker).notifier.counts())
+}
+
+func TestSlowCallRatio(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	var tripped Stats
+	b := NewBreaker(WithSlowCallThreshold(time.Second),
+		WithThreeState(WithClock(clk), WithTripPolicy(SlowCallRatio(0.5, 4))))
+	b.OnStateChange(func(name string, from, to State, stats Stats) {
+		tripped = stats
+	})
+	slow := func() error {
+		clk.Advance(2 * time.Second)
+		return nil
+	}
+
+	// 请求数不足 4 个时不熔断
+	assert.Nil(t, b.Do(succeed))
+	assert.Nil(t, b.Do(slow))
+	assert.Nil(t, b.Do(slow))
+	// 成功的请求之后同样检查熔断策略
+	assert.Nil(t, b.Do(succeed))
+	assert.Equal(t, ErrServiceUnavailable, b.Do(succeed))
+	assert.Equal(t, Stats{Requests: 4, Failures: 2, SlowCalls: 2, SlowCallRequests: 4}, tripped)
+}
+
+func TestSlowCallRatioIgnored(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	errBusiness := errors.New("business")
+	var tripped Stats
+	b := NewBreaker(WithSlowCallThreshold(time.Second), WithClassifiers(ErrorsIs(Ignored, errBusiness)),
+		WithThreeState(WithClock(clk), WithTripPolicy(SlowCallRatio(0.5, 4))))
+	b.OnStateChange(func(name string, from, to State, stats Stats) {
+		tripped = stats
+	})
+
+	// 真实错误被忽略的慢调用不计入失败，但仍然计入慢调用比例
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, errBusiness, b.Do(func() error {
+			clk.Advance(2 * time.Second)
+			return errBusiness
+		}))
+	}
+	assert.Equal(t, ErrServiceUnavailable, b.Do(succeed))
+	assert.Equal(t, Stats{SlowCalls: 4, SlowCallRequests: 4}, tripped)
+}
+
+func TestGoogleBreakerSlowCall(t *testing.T) {
+	b := NewBreaker(WithSlowCallThreshold(time.Nanosecond))
+	errSlow := errors.New("slow")
+
+	assert.Nil(t, b.Do(func() error {
+		time.Sleep(time.Millisecond)
+		return nil
+	}))
+	// 已经失败的慢调用按原错误归类
+	assert.Equal(t, errSlow, b.Do(func() error {
+		time.Sleep(time.Millisecond)
+		return errSlow
+	}))
+	assert.Equal(t, Counts{Rejects: 2}, b.(*circuitBreaker).notifier.counts())
+}
+
+func TestAnyOf(t *testing.T) {
+	policy := AnyOf(ErrorRatio(0.5, 10), SlowCallRatio(0.5, 10))
+	assert.False(t, policy(Stats{Requests: 10, Failures: 4, SlowCalls: 4, SlowCallRequests: 10}))
+	assert.True(t, policy(Stats{Requests: 10, Failures: 5, SlowCallRequests: 10}))
+	assert.True(t, policy(Stats{Requests: 10, SlowCalls: 5, SlowCallRequests: 10}))
+}
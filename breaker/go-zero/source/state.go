@@ -0,0 +1,60 @@
+package breaker
+
+// 熔断器状态
+const (
+	// StateClosed 关闭，放行全部请求
+	StateClosed State = iota
+	// StateOpen 打开，拒绝全部请求
+	StateOpen
+	// StateHalfOpen 半开，放行少量探测请求
+	StateHalfOpen
+)
+
+type (
+	// State 熔断器状态
+	// google 算法没有半开状态，开始丢弃请求时为打开，丢弃概率回落到 0 时为关闭
+	State int
+
+	// Stats 熔断器在统计窗口内的请求情况
+	Stats struct {
+		Requests            int64 // 请求总数
+		Failures            int64 // 失败数
+		ConsecutiveFailures int64 // 连续失败数，仅三态熔断器统计
+		SlowCalls           int64 // 慢调用数，仅三态熔断器统计
+		SlowCallRequests    int64 // 参与慢调用统计的请求数，包含结果被忽略的请求，仅三态熔断器统计
+	}
+
+	// stateListener 内部算法状态变化时的回调
+	stateListener func(from, to State, stats Stats)
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// ErrorRatio 失败率
+func (s Stats) ErrorRatio() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+
+	return float64(s.Failures) / float64(s.Requests)
+}
+
+// SlowCallRatio 慢调用比例
+func (s Stats) SlowCallRatio() float64 {
+	if s.SlowCallRequests == 0 {
+		return 0
+	}
+
+	return float64(s.SlowCalls) / float64(s.SlowCallRequests)
+}
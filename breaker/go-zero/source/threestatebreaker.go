@@ -0,0 +1,338 @@
+package breaker
+
+import (
+	"sync"
+	"time"
+
+	collection "go-zero-source/rollingwindow/go-zero"
+	"go-zero-source/util/clock"
+)
+
+const (
+	// 统计窗口与 google 算法一致，总时间 10s，每个桶 250ms
+	threeStateWindow  = time.Second * 10
+	threeStateBuckets = 40
+
+	defaultConsecutiveFailures = 5
+	defaultOpenDuration        = time.Second * 5
+	defaultHalfOpenProbes      = 1
+)
+
+type (
+	// TripPolicy 熔断策略，关闭状态下每次请求结束后调用，返回 true 时熔断器打开
+	TripPolicy func(stats Stats) bool
+
+	// ThreeStateOption 定义了三态熔断器的自定义方法
+	ThreeStateOption func(b *threeStateBreaker)
+
+	// threeStateBreaker 经典的三态熔断器
+	// 关闭：放行全部请求，满足熔断策略时打开
+	// 打开：拒绝全部请求，经过 openDuration 后进入半开
+	// 半开：最多放行 probes 个探测请求，全部成功则关闭，任一失败则重新打开
+	threeStateBreaker struct {
+		lock         sync.Mutex
+		policy       TripPolicy
+		openDuration time.Duration
+		probes       int
+		clock        clock.Clock
+
+		state      State
+		generation uint64 // 每次状态变化时递增，丢弃旧状态下请求的结果
+		openedAt   time.Time
+		// 关闭状态下的统计，Sum 为失败数，Count 为请求总数
+		stat *collection.RollingWindow
+		// 关闭状态下的慢调用统计，Sum 为慢调用数
+		slowStat            *collection.RollingWindow
+		slowCall            slowCall
+		consecutiveFailures int64
+		// 半开状态下已放行与已成功的探测请求数
+		probed    int
+		succeeded int
+
+		listener stateListener
+		// 持有锁期间发生的状态变化，释放锁之后再回调，回调中可以安全地调用熔断器
+		pending []stateChange
+	}
+
+	stateChange struct {
+		from, to State
+		stats    Stats
+	}
+
+	threeStatePromise struct {
+		b          *threeStateBreaker
+		generation uint64
+	}
+)
+
+// ConsecutiveFailures 连续失败 n 次时熔断
+func ConsecutiveFailures(n int64) TripPolicy {
+	return func(stats Stats) bool {
+		return stats.ConsecutiveFailures >= n
+	}
+}
+
+// ErrorRatio 统计窗口内请求数不少于 minRequests 且失败率不低于 ratio 时熔断
+func ErrorRatio(ratio float64, minRequests int64) TripPolicy {
+	return func(stats Stats) bool {
+		return stats.Requests >= minRequests && stats.ErrorRatio() >= ratio
+	}
+}
+
+// SlowCallRatio 统计窗口内请求数不少于 minRequests 且慢调用比例不低于 ratio 时熔断
+// 需要通过 WithSlowCallThreshold 设置慢调用阈值
+func SlowCallRatio(ratio float64, minRequests int64) TripPolicy {
+	return func(stats Stats) bool {
+		return stats.SlowCallRequests >= minRequests && stats.SlowCallRatio() >= ratio
+	}
+}
+
+// AnyOf 满足任一熔断策略时熔断
+func AnyOf(policies ...TripPolicy) TripPolicy {
+	return func(stats Stats) bool {
+		for _, policy := range policies {
+			if policy(stats) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// WithThreeState 使用三态熔断器替代 google 自适应算法
+func WithThreeState(opts ...ThreeStateOption) Option {
+	return func(b *circuitBreaker) {
+		b.internal = newThreeStateBreaker(opts...)
+	}
+}
+
+// WithTripPolicy 设置熔断策略，默认连续失败 5 次熔断
+func WithTripPolicy(policy TripPolicy) ThreeStateOption {
+	return func(b *threeStateBreaker) {
+		b.policy = policy
+	}
+}
+
+// WithOpenDuration 设置打开状态的持续时间，默认 5s
+func WithOpenDuration(d time.Duration) ThreeStateOption {
+	return func(b *threeStateBreaker) {
+		b.openDuration = d
+	}
+}
+
+// WithHalfOpenProbes 设置半开状态放行的探测请求数，默认 1
+func WithHalfOpenProbes(n int) ThreeStateOption {
+	return func(b *threeStateBreaker) {
+		b.probes = n
+	}
+}
+
+// WithClock 指定时钟，测试时可以传入 clocktest.FakeClock
+func WithClock(c clock.Clock) ThreeStateOption {
+	return func(b *threeStateBreaker) {
+		b.clock = c
+	}
+}
+
+func newThreeStateBreaker(opts ...ThreeStateOption) *threeStateBreaker {
+	b := &threeStateBreaker{
+		policy:       ConsecutiveFailures(defaultConsecutiveFailures),
+		openDuration: defaultOpenDuration,
+		probes:       defaultHalfOpenProbes,
+		clock:        clock.Default,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	b.stat = b.newStat()
+	b.slowStat = b.newStat()
+
+	return b
+}
+
+func (b *threeStateBreaker) allow() (internalPromise, error) {
+	generation, err := b.beforeRequest()
+	if err != nil {
+		return nil, err
+	}
+
+	return threeStatePromise{
+		b:          b,
+		generation: generation,
+	}, nil
+}
+
+func (b *threeStateBreaker) doReq(req func() error, fallback func(err error) error,
+	classify func(err, slowErr error) Outcome) error {
+	generation, err := b.beforeRequest()
+	if err != nil {
+		if fallback != nil {
+			return fallback(err)
+		}
+
+		return err
+	}
+
+	defer func() {
+		if e := recover(); e != nil {
+			b.afterRequest(generation, Failure, false)
+			panic(e)
+		}
+	}()
+
+	// 归类真实的错误后再应用慢调用规则，无论结果如何慢调用都计入慢调用统计
+	start := b.slowCall.start()
+	err = req()
+	slowErr := b.slowCall.judge(start)
+	b.afterRequest(generation, classify(err, slowErr), slowErr != nil)
+
+	return err
+}
+
+func (b *threeStateBreaker) setStateListener(listener stateListener) {
+	b.listener = listener
+}
+
+func (b *threeStateBreaker) setSlowCallThreshold(threshold time.Duration) {
+	b.slowCall = newSlowCall(threshold, b.clock)
+}
+
+// unlock 释放锁，并回调持有锁期间发生的状态变化
+func (b *threeStateBreaker) unlock() {
+	pending := b.pending
+	b.pending = nil
+	b.lock.Unlock()
+
+	if b.listener == nil {
+		return
+	}
+	for _, change := range pending {
+		b.listener(change.from, change.to, change.stats)
+	}
+}
+
+// beforeRequest 判断请求是否放行，放行时返回当前的状态版本
+func (b *threeStateBreaker) beforeRequest() (uint64, error) {
+	b.lock.Lock()
+	defer b.unlock()
+
+	switch b.currentState() {
+	case StateOpen:
+		return 0, ErrServiceUnavailable
+	case StateHalfOpen:
+		if b.probed >= b.probes {
+			return 0, ErrServiceUnavailable
+		}
+		b.probed++
+	}
+
+	return b.generation, nil
+}
+
+// afterRequest 记录请求结果，状态已经变化时忽略
+func (b *threeStateBreaker) afterRequest(generation uint64, result Outcome, slow bool) {
+	b.lock.Lock()
+	defer b.unlock()
+
+	state := b.currentState()
+	if generation != b.generation {
+		return
+	}
+
+	switch state {
+	case StateClosed:
+		// 慢调用在分类之前记录，被归类为成功或忽略的慢调用同样计入慢调用比例
+		if slow {
+			b.slowStat.Add(1)
+		} else {
+			b.slowStat.Add(0)
+		}
+		switch result {
+		case Success:
+			b.consecutiveFailures = 0
+			b.stat.Add(0)
+		case Failure:
+			b.consecutiveFailures++
+			b.stat.Add(1)
+		}
+
+		// 每次记录后都检查熔断策略，慢调用比例等策略不依赖失败
+		if b.policy(b.stats()) {
+			b.setState(StateOpen)
+		}
+	case StateHalfOpen:
+		switch result {
+		case Ignored:
+			// 中性结果不计入统计，归还探测名额
+			b.probed--
+		case Success:
+			b.succeeded++
+			if b.succeeded >= b.probes {
+				b.setState(StateClosed)
+			}
+		default:
+			b.setState(StateOpen)
+		}
+	}
+}
+
+func (b *threeStateBreaker) currentState() State {
+	if b.state == StateOpen && b.clock.Since(b.openedAt) >= b.openDuration {
+		b.setState(StateHalfOpen)
+	}
+
+	return b.state
+}
+
+func (b *threeStateBreaker) setState(state State) {
+	b.pending = append(b.pending, stateChange{
+		from:  b.state,
+		to:    state,
+		stats: b.stats(),
+	})
+	b.state = state
+	b.generation++
+
+	switch state {
+	case StateClosed:
+		b.stat = b.newStat()
+		b.slowStat = b.newStat()
+		b.consecutiveFailures = 0
+	case StateOpen:
+		b.openedAt = b.clock.Now()
+	case StateHalfOpen:
+		b.probed = 0
+		b.succeeded = 0
+	}
+}
+
+// stats 关闭状态下统计窗口内的请求情况
+func (b *threeStateBreaker) stats() Stats {
+	stats := Stats{ConsecutiveFailures: b.consecutiveFailures}
+	b.stat.Reduce(func(bucket *collection.Bucket) {
+		stats.Failures += int64(bucket.Sum)
+		stats.Requests += bucket.Count
+	})
+	b.slowStat.Reduce(func(bucket *collection.Bucket) {
+		stats.SlowCalls += int64(bucket.Sum)
+		stats.SlowCallRequests += bucket.Count
+	})
+
+	return stats
+}
+
+func (b *threeStateBreaker) newStat() *collection.RollingWindow {
+	return collection.NewRollingWindow(threeStateBuckets, threeStateWindow/threeStateBuckets,
+		collection.WithClock(b.clock))
+}
+
+// Accept 请求成功
+func (p threeStatePromise) Accept() {
+	p.b.afterRequest(p.generation, Success, false)
+}
+
+// Reject 请求失败
+func (p threeStatePromise) Reject() {
+	p.b.afterRequest(p.generation, Failure, false)
+}
@@ -0,0 +1,108 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"go-zero-source/util/clock/clocktest"
+)
+
+var errTest = errors.New("test")
+
+func fail() error {
+	return errTest
+}
+
+func succeed() error {
+	return nil
+}
+
+func TestThreeStateBreakerConsecutiveFailures(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	b := NewBreaker(WithThreeState(WithClock(clk), WithTripPolicy(ConsecutiveFailures(3)),
+		WithOpenDuration(time.Second), WithHalfOpenProbes(2)))
+
+	// 成功会重置连续失败数
+	assert.Equal(t, errTest, b.Do(fail))
+	assert.Equal(t, errTest, b.Do(fail))
+	assert.Nil(t, b.Do(succeed))
+	assert.Equal(t, errTest, b.Do(fail))
+	assert.Equal(t, errTest, b.Do(fail))
+	assert.Equal(t, errTest, b.Do(fail))
+	assert.Equal(t, ErrServiceUnavailable, b.Do(succeed))
+
+	// 打开状态持续 1s 后进入半开，放行 2 个探测请求
+	clk.Advance(time.Second)
+	p1, err := b.Allow()
+	assert.Nil(t, err)
+	p2, err := b.Allow()
+	assert.Nil(t, err)
+	_, err = b.Allow()
+	assert.Equal(t, ErrServiceUnavailable, err)
+
+	// 探测请求全部成功后关闭
+	p1.Accept()
+	assert.Equal(t, ErrServiceUnavailable, b.Do(succeed))
+	p2.Accept()
+	assert.Nil(t, b.Do(succeed))
+}
+
+func TestThreeStateBreakerHalfOpenFailure(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	b := NewBreaker(WithThreeState(WithClock(clk), WithTripPolicy(ConsecutiveFailures(1))))
+
+	assert.Equal(t, errTest, b.Do(fail))
+	clk.Advance(defaultOpenDuration)
+
+	// 探测请求失败，重新打开
+	p, err := b.Allow()
+	assert.Nil(t, err)
+	p.Reject("probe failed")
+	assert.Equal(t, ErrServiceUnavailable, b.Do(succeed))
+
+	clk.Advance(defaultOpenDuration)
+	assert.Nil(t, b.Do(succeed))
+	assert.Nil(t, b.Do(succeed))
+}
+
+func TestThreeStateBreakerErrorRatio(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	b := NewBreaker(WithThreeState(WithClock(clk), WithTripPolicy(ErrorRatio(0.5, 10))))
+
+	// 请求数不足时不熔断
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, errTest, b.Do(fail))
+	}
+	for i := 0; i < 4; i++ {
+		assert.Nil(t, b.Do(succeed))
+	}
+	// 第 10 个请求失败，失败率 60%
+	assert.Equal(t, errTest, b.Do(fail))
+	assert.Equal(t, ErrServiceUnavailable, b.Do(succeed))
+
+	fallback := errors.New("fallback")
+	assert.Equal(t, fallback, b.DoWithFallback(succeed, func(err error) error {
+		assert.Equal(t, ErrServiceUnavailable, err)
+		return fallback
+	}))
+}
+
+func TestThreeStateBreakerAcceptable(t *testing.T) {
+	b := NewBreaker(WithThreeState(WithTripPolicy(ConsecutiveFailures(1))))
+
+	// 可接受的错误不计为失败
+	assert.Equal(t, errTest, b.DoWithAcceptable(fail, func(err error) bool {
+		return true
+	}))
+	assert.Nil(t, b.Do(succeed))
+
+	assert.Panics(t, func() {
+		_ = b.Do(func() error {
+			panic("boom")
+		})
+	})
+	assert.Equal(t, ErrServiceUnavailable, b.Do(succeed))
+}
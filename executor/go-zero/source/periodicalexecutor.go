@@ -38,6 +38,7 @@ type (
 		inflight  int32                                     // 用来判断是否可以退出当前 backgroundFlush
 		guarded   bool                                      // 为 false 时，允许启动 backgroundFlush
 		newTicker func(duration time.Duration) timex.Ticker // 时间间隔器
+		onExecute func(size int, elapsed time.Duration)     // 每次执行后的回调，用于监控
 		lock      sync.Mutex
 	}
 )
@@ -86,6 +87,12 @@ func (pe *PeriodicalExecutor) Flush() bool {
 	}())
 }
 
+// OnExecute 设置每次执行后的回调，size 为本次执行的 task 数量（无法计算时为 1），elapsed 为执行耗时
+// 需要在第一次 Add 之前设置
+func (pe *PeriodicalExecutor) OnExecute(fn func(size int, elapsed time.Duration)) {
+	pe.onExecute = fn
+}
+
 // Sync 线程安全的执行 fn
 func (pe *PeriodicalExecutor) Sync(fn func()) {
 	pe.lock.Lock()
@@ -194,16 +201,31 @@ func (pe *PeriodicalExecutor) executeTasks(tasks any) bool {
 	ok := pe.hasTasks(tasks)
 	// 只有有 task，就执行 Execute 方法
 	if ok {
+		start := timex.Now()
 		// 同步调用
 		threading.RunSafe(func() {
 			// 实际调用的是使用方实现的 Execute 方法
 			pe.container.Execute(tasks)
 		})
+		if pe.onExecute != nil {
+			pe.onExecute(taskSize(tasks), timex.Since(start))
+		}
 	}
 
 	return ok
 }
 
+// taskSize 计算 tasks 的数量，未知类型按 1 个计算
+func taskSize(tasks any) int {
+	val := reflect.ValueOf(tasks)
+	switch val.Kind() {
+	case reflect.Array, reflect.Chan, reflect.Map, reflect.Slice:
+		return val.Len()
+	default:
+		return 1
+	}
+}
+
 func (pe *PeriodicalExecutor) hasTasks(tasks any) bool {
 	if tasks == nil {
 		return false
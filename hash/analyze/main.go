@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go-zero-source/hash/hash/source"
+	"strconv"
+	"strings"
+)
+
+var (
+	nodes    = flag.String("nodes", "localhost:8080,localhost:8081,localhost:8082,localhost:8083,localhost:8084", "真实节点列表，逗号分隔")
+	replicas = flag.Int("replicas", 100, "每个真实节点的虚拟节点个数，最小 100")
+	keys     = flag.Int("keys", 100000, "模拟请求的 key 数量")
+)
+
+// 打印哈希环的均衡性统计
+// go run ./hash/analyze -nodes a,b,c -replicas 200 -keys 100000
+func main() {
+	flag.Parse()
+
+	dispatcher := source.NewCustomConsistentHash(nil, nil, *replicas)
+	for _, node := range strings.Split(*nodes, ",") {
+		if node = strings.TrimSpace(node); len(node) > 0 {
+			dispatcher.Add(node)
+		}
+	}
+
+	sample := make([]string, *keys)
+	for i := range sample {
+		sample[i] = strconv.Itoa(i)
+	}
+	counts := dispatcher.Simulate(sample)
+
+	analysis := dispatcher.Analyze()
+	fmt.Printf("%-24s %8s %10s %10s\n", "node", "vnodes", "space", "keys")
+	for _, stat := range analysis.Nodes {
+		fmt.Printf("%-24s %8d %9.02f%% %9.02f%%\n", stat.Node, stat.VirtualNodes,
+			stat.Fraction*100, float64(counts[stat.Node])/float64(*keys)*100)
+	}
+	fmt.Printf("哈希空间占比标准差: %.04f\n", analysis.StdDev)
+	fmt.Printf("哈希空间最大/最小占比: %.04f\n", analysis.MaxMinRatio)
+}
@@ -0,0 +1,140 @@
+package source
+
+import (
+	"context"
+	"math"
+	"sort"
+)
+
+// 64 位哈希空间大小
+const hashSpace = float64(1<<63) * 2
+
+type (
+	// NodeStat 真实节点在哈希环上的分布情况
+	NodeStat struct {
+		Node         string  // 真实节点
+		VirtualNodes int     // 虚拟节点个数
+		Fraction     float64 // 占有的哈希空间比例
+	}
+
+	// Analysis 哈希环的均衡性统计
+	Analysis struct {
+		Nodes       []NodeStat // 各真实节点的分布，按节点名排序
+		StdDev      float64    // 各节点占比的标准差
+		MaxMinRatio float64    // 最大占比与最小占比的比值，越接近 1 越均衡
+	}
+)
+
+// Analyze 统计各真实节点在 64 位哈希空间中占有的比例
+// 每个虚拟节点占有 (上一个虚拟节点, 当前虚拟节点] 区间，发生哈希冲突的虚拟节点由冲突的真实节点平分
+// 结果假设哈希函数的输出均匀分布在整个 64 位空间，哈希环不支持遍历或出错时返回空结果
+func (h *ConsistentHash) Analyze() Analysis {
+	ctx := context.Background()
+	unlock, err := h.readLock(ctx)
+	if err != nil {
+		return Analysis{}
+	}
+	defer unlock()
+
+	walker, ok := h.hashRing.(WalkHashRing)
+	if !ok {
+		return Analysis{}
+	}
+
+	// 按顺时针顺序收集虚拟节点，合并同一位置上的真实节点
+	type point struct {
+		virtualNode uint64
+		nodes       []string
+	}
+	var points []*point
+	err = walker.Walk(ctx, 0, func(virtualNode uint64, node string) bool {
+		if len(points) == 0 || points[len(points)-1].virtualNode != virtualNode {
+			points = append(points, &point{virtualNode: virtualNode})
+		}
+		last := points[len(points)-1]
+		for _, n := range last.nodes {
+			if n == node {
+				return true
+			}
+		}
+		last.nodes = append(last.nodes, node)
+		return true
+	})
+	if err != nil || len(points) == 0 {
+		return Analysis{}
+	}
+
+	stats := make(map[string]*NodeStat)
+	for i, p := range points {
+		// 第一个虚拟节点的区间需要绕环，uint64 减法溢出正好得到绕环后的长度
+		prev := points[(i+len(points)-1)%len(points)].virtualNode
+		arc := float64(p.virtualNode-prev) / hashSpace
+		if len(points) == 1 {
+			arc = 1
+		}
+
+		for _, node := range p.nodes {
+			stat, ok := stats[node]
+			if !ok {
+				stat = &NodeStat{Node: node}
+				stats[node] = stat
+			}
+			stat.VirtualNodes++
+			stat.Fraction += arc / float64(len(p.nodes))
+		}
+	}
+
+	var analysis Analysis
+	for _, stat := range stats {
+		analysis.Nodes = append(analysis.Nodes, *stat)
+	}
+	sort.Slice(analysis.Nodes, func(i, j int) bool { return analysis.Nodes[i].Node < analysis.Nodes[j].Node })
+
+	fractions := make([]float64, len(analysis.Nodes))
+	for i, stat := range analysis.Nodes {
+		fractions[i] = stat.Fraction
+	}
+	analysis.StdDev, analysis.MaxMinRatio = distribution(fractions)
+
+	return analysis
+}
+
+// Simulate 使用 Get 对样本 key 进行路由，返回各真实节点命中的 key 数量
+func (h *ConsistentHash) Simulate(keys []string) map[string]int {
+	counts := make(map[string]int)
+	for _, key := range keys {
+		if node, ok := h.Get(key); ok {
+			counts[node]++
+		}
+	}
+
+	return counts
+}
+
+// 计算标准差和最大最小值的比值
+func distribution(values []float64) (stdDev, maxMinRatio float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+
+	var sum float64
+	minVal, maxVal := math.MaxFloat64, 0.0
+	for _, v := range values {
+		sum += v
+		minVal = math.Min(minVal, v)
+		maxVal = math.Max(maxVal, v)
+	}
+
+	mean := sum / float64(len(values))
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	stdDev = math.Sqrt(variance / float64(len(values)))
+
+	if minVal == 0 {
+		return stdDev, math.Inf(1)
+	}
+
+	return stdDev, maxVal / minVal
+}
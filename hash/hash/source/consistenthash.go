@@ -1,8 +1,11 @@
 package source
 
 import (
+	"context"
+	breaker "go-zero-source/breaker/go-zero/source"
 	"go-zero-source/hash/hash/source/local"
 	"strconv"
+	"sync"
 )
 
 const (
@@ -17,6 +20,10 @@ type (
 		hashRing HashRing // 哈希环
 		hashFunc HashFunc // 哈希函数
 		replicas int      // 添加真实节点时，结合权重，添加对应数量的虚拟节点
+
+		down       map[string]struct{}        // 被临时摘除的真实节点，仍在环上，但查询时跳过
+		breakers   map[string]breaker.Breaker // 真实节点对应的熔断器
+		healthLock sync.RWMutex
 	}
 )
 
@@ -42,61 +49,202 @@ func NewCustomConsistentHash(hashRing HashRing, hashFunc HashFunc, replicas int)
 		hashRing: hashRing,
 		hashFunc: hashFunc,
 		replicas: replicas,
+		down:     make(map[string]struct{}),
+		breakers: make(map[string]breaker.Breaker),
 	}
 }
 
-// Add 添加真实节点
+// Add 添加真实节点，哈希环出错时 panic，建议使用 AddCtx
 func (h *ConsistentHash) Add(node string) {
+	if err := h.AddCtx(context.Background(), node); err != nil {
+		panic(err)
+	}
+}
+
+// AddCtx 添加真实节点，返回哈希环的错误
+func (h *ConsistentHash) AddCtx(ctx context.Context, node string) (err error) {
 	// 支持重复添加
 	// 先删除该真实节点
-	h.Remove(node)
+	if err = h.RemoveCtx(ctx, node); err != nil {
+		return err
+	}
 
 	// 加锁
-	h.hashRing.Lock()
-	defer h.hashRing.Unlock()
+	if err = h.hashRing.Lock(ctx); err != nil {
+		return err
+	}
+	defer func() {
+		if e := h.hashRing.Unlock(); e != nil && err == nil {
+			err = e
+		}
+	}()
 
 	for i := 0; i < h.replicas; i++ {
 		// 计算虚拟节点的哈希值
 		virtualNode := h.hashFunc([]byte(node + strconv.Itoa(i)))
 		// 添加节点
-		err := h.hashRing.AddNode(node, virtualNode, i)
-		if err != nil {
-			panic(err)
+		if err = h.hashRing.AddNode(ctx, node, virtualNode, i); err != nil {
+			return err
 		}
 	}
+
+	return nil
 }
 
-// Remove 删除真实节点
+// Remove 删除真实节点，哈希环出错时 panic，建议使用 RemoveCtx
 func (h *ConsistentHash) Remove(node string) {
+	if err := h.RemoveCtx(context.Background(), node); err != nil {
+		panic(err)
+	}
+}
+
+// RemoveCtx 删除真实节点，返回哈希环的错误
+func (h *ConsistentHash) RemoveCtx(ctx context.Context, node string) (err error) {
 	// 加锁
-	h.hashRing.Lock()
-	defer h.hashRing.Unlock()
+	if err = h.hashRing.Lock(ctx); err != nil {
+		return err
+	}
+	defer func() {
+		if e := h.hashRing.Unlock(); e != nil && err == nil {
+			err = e
+		}
+	}()
 
 	// 检查节点是否存在哈希环中，不存在直接返回
-	if !h.hashRing.ContainsNode(node) {
-		return
+	ok, err := h.hashRing.ContainsNode(ctx, node)
+	if err != nil || !ok {
+		return err
 	}
 
 	for i := 0; i < h.replicas; i++ {
 		// 计算虚拟节点的哈希值
 		virtualNode := h.hashFunc([]byte(node + strconv.Itoa(i)))
 		// 删除节点
-		err := h.hashRing.RemoveNode(node, virtualNode, i)
-		if err != nil {
-			panic(err)
+		if err = h.hashRing.RemoveNode(ctx, node, virtualNode, i); err != nil {
+			return err
 		}
 	}
+
+	// 节点已不在环上，清除健康状态
+	h.forget(node)
+
+	return nil
 }
 
-// Get 查询节点，最终返回具体的真实节点
+// Get 查询节点，最终返回具体的真实节点，哈希环出错时返回 false
 func (h *ConsistentHash) Get(key string) (string, bool) {
-	// 加锁
-	h.hashRing.Lock()
-	defer h.hashRing.Unlock()
+	node, ok, err := h.GetCtx(context.Background(), key)
+	if err != nil {
+		return "", false
+	}
+
+	return node, ok
+}
+
+// GetCtx 查询节点，返回哈希环的错误
+// 被摘除的节点会被跳过，顺时针找到下一个健康节点
+func (h *ConsistentHash) GetCtx(ctx context.Context, key string) (string, bool, error) {
+	unlock, err := h.readLock(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	defer unlock()
 
 	// 计算key的哈希值
 	hash := h.hashFunc([]byte(key))
 
 	// 获取对应的真实节点
-	return h.hashRing.GetNode(hash)
+	node, ok, err := h.hashRing.GetNode(ctx, hash)
+	if err != nil || !ok || h.IsHealthy(node) {
+		return node, ok, err
+	}
+
+	// 节点被摘除，顺时针寻找下一个健康节点
+	return h.next(ctx, hash, h.IsHealthy)
+}
+
+// GetN 返回 key 顺时针方向上最多 n 个不同的健康真实节点，第一个为 Get 返回的节点，哈希环出错时返回空
+func (h *ConsistentHash) GetN(key string, n int) []string {
+	nodes, _ := h.GetNCtx(context.Background(), key, n)
+	return nodes
+}
+
+// GetNCtx 返回 key 顺时针方向上最多 n 个不同的健康真实节点，返回哈希环的错误
+// 哈希环不支持遍历时最多返回 Get 得到的一个节点
+func (h *ConsistentHash) GetNCtx(ctx context.Context, key string, n int) ([]string, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+
+	node, ok, err := h.GetCtx(ctx, key)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	nodes := []string{node}
+	if n == 1 {
+		return nodes, nil
+	}
+
+	unlock, err := h.readLock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// 从第一个节点开始继续顺时针收集，next 会对真实节点去重
+	_, _, err = h.next(ctx, h.hashFunc([]byte(key)), func(candidate string) bool {
+		if candidate == node || !h.IsHealthy(candidate) {
+			return false
+		}
+		nodes = append(nodes, candidate)
+		return len(nodes) >= n
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return nodes, nil
+}
+
+// 查询加锁，返回解锁函数
+func (h *ConsistentHash) readLock(ctx context.Context) (func(), error) {
+	return readLock(ctx, h.hashRing)
+}
+
+// 从 hash 开始顺时针找到第一个满足 accept 的真实节点
+// 哈希环不支持遍历时只检查 hash 所在的节点
+func (h *ConsistentHash) next(ctx context.Context, hash uint64, accept func(node string) bool) (string, bool, error) {
+	walker, ok := h.hashRing.(WalkHashRing)
+	if !ok {
+		node, ok, err := h.hashRing.GetNode(ctx, hash)
+		if err != nil || !ok || !accept(node) {
+			return "", false, err
+		}
+
+		return node, true, nil
+	}
+
+	var (
+		found   string
+		visited = make(map[string]struct{})
+	)
+	err := walker.Walk(ctx, hash, func(_ uint64, node string) bool {
+		// 同一个真实节点有多个虚拟节点，只检查一次
+		if _, ok := visited[node]; ok {
+			return true
+		}
+		visited[node] = struct{}{}
+
+		if accept(node) {
+			found = node
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return "", false, err
+	}
+
+	return found, len(found) > 0, nil
 }
@@ -1,10 +1,15 @@
 package source
 
 import (
+	"context"
+	"errors"
+	"go-zero-source/hash/hash/source/local"
 	"go-zero-source/hash/hash/source/redis"
 	"strconv"
 	"testing"
+	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -78,3 +83,218 @@ func TestConsistentHashRedis(t *testing.T) {
 		assert.Equal(t, "second", val)
 	}
 }
+
+func TestConsistentHashCachedRedis(t *testing.T) {
+	m := miniredis.RunT(t)
+	cached, err := redis.NewCachedHashRing(redis.NewZSetHashRing("hashRing", m.Addr(), ""), time.Minute)
+	assert.Nil(t, err)
+	defer cached.Close()
+	ch := NewCustomConsistentHash(cached, redis.Hash, minReplicas)
+
+	ch.Add("first")
+	for i := 0; i < 100; i++ {
+		val, ok := ch.Get(strconv.Itoa(i))
+		assert.True(t, ok)
+		assert.Equal(t, "first", val)
+	}
+
+	ch.Add("second")
+	ch.Remove("first")
+
+	for i := 0; i < 100; i++ {
+		val, ok := ch.Get(strconv.Itoa(i))
+		assert.True(t, ok)
+		assert.Equal(t, "second", val)
+	}
+}
+
+func TestConsistentHash_MarkDown(t *testing.T) {
+	ch := NewConsistentHash()
+	nodes := []string{"first", "second", "third"}
+	for _, node := range nodes {
+		ch.Add(node)
+	}
+
+	origin := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		origin[key], _ = ch.Get(key)
+	}
+
+	ch.MarkDown("first")
+	assert.False(t, ch.IsHealthy("first"))
+	for key, node := range origin {
+		val, ok := ch.Get(key)
+		assert.True(t, ok)
+		if node == "first" {
+			assert.NotEqual(t, "first", val)
+		} else {
+			// 其他节点的 key 不受影响
+			assert.Equal(t, node, val)
+		}
+	}
+
+	// 恢复后 key 回到原节点
+	ch.MarkUp("first")
+	for key, node := range origin {
+		val, _ := ch.Get(key)
+		assert.Equal(t, node, val)
+	}
+
+	for _, node := range nodes {
+		ch.MarkDown(node)
+	}
+	_, ok := ch.Get("any")
+	assert.False(t, ok)
+}
+
+func TestConsistentHash_Do(t *testing.T) {
+	ch := NewConsistentHash()
+	ch.Add("first")
+	ch.Add("second")
+
+	assert.ErrorIs(t, NewConsistentHash().Do("any", func(string) error { return nil }), ErrNoAvailableNode)
+
+	var key string
+	for i := 0; ; i++ {
+		key = strconv.Itoa(i)
+		if node, _ := ch.Get(key); node == "first" {
+			break
+		}
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		_ = ch.Do(key, func(node string) error {
+			counts[node]++
+			if node == "first" {
+				return errors.New("fail")
+			}
+			return nil
+		})
+	}
+
+	// first 持续失败后被熔断，请求转移到 second
+	assert.True(t, counts["first"] < 100)
+	assert.True(t, counts["second"] > 0)
+}
+
+// noWalkRing 隐藏 Walk 方法，模拟不支持遍历的哈希环
+type noWalkRing struct {
+	HashRing
+}
+
+func TestConsistentHash_DoWithoutWalk(t *testing.T) {
+	ch := NewCustomConsistentHash(noWalkRing{local.NewSliceHashRing()}, Hash, minReplicas)
+	assert.ErrorIs(t, ch.Do("any", func(string) error { return nil }), ErrNoAvailableNode)
+
+	ch.Add("first")
+	ch.Add("second")
+	primary, ok := ch.Get("key")
+	assert.True(t, ok)
+
+	// 不支持遍历时使用 key 所在的节点
+	var used string
+	assert.Nil(t, ch.Do("key", func(node string) error {
+		used = node
+		return nil
+	}))
+	assert.Equal(t, primary, used)
+	assert.Equal(t, []string{primary}, ch.GetN("key", 2))
+
+	// 无法转移到其他节点
+	ch.MarkDown(primary)
+	assert.ErrorIs(t, ch.Do("key", func(string) error { return nil }), ErrNoAvailableNode)
+	_, ok = ch.Get("key")
+	assert.False(t, ok)
+}
+
+func TestConsistentHash_Analyze(t *testing.T) {
+	ch := NewConsistentHash()
+	assert.Empty(t, ch.Analyze().Nodes)
+
+	ch.Add("first")
+	analysis := ch.Analyze()
+	assert.Len(t, analysis.Nodes, 1)
+	assert.Equal(t, minReplicas, analysis.Nodes[0].VirtualNodes)
+	assert.InDelta(t, 1, analysis.Nodes[0].Fraction, 1e-9)
+	assert.Equal(t, float64(1), analysis.MaxMinRatio)
+
+	for i := 0; i < keySize; i++ {
+		ch.Add("localhost:" + strconv.Itoa(i))
+	}
+	analysis = ch.Analyze()
+	assert.Len(t, analysis.Nodes, keySize+1)
+
+	var total float64
+	for _, stat := range analysis.Nodes {
+		total += stat.Fraction
+	}
+	assert.InDelta(t, 1, total, 1e-9)
+	assert.True(t, analysis.MaxMinRatio >= 1)
+	assert.True(t, analysis.StdDev > 0)
+
+	// 模拟结果与哈希空间占比接近
+	sample := make([]string, 100000)
+	for i := range sample {
+		sample[i] = strconv.Itoa(i)
+	}
+	counts := ch.Simulate(sample)
+	for _, stat := range analysis.Nodes {
+		assert.InDelta(t, stat.Fraction, float64(counts[stat.Node])/float64(len(sample)), 0.01)
+	}
+}
+
+func TestConsistentHashCtxError(t *testing.T) {
+	m := miniredis.RunT(t)
+	ring := redis.NewZSetHashRing("hashRing", m.Addr(), "", redis.WithRetries(0))
+	ch := NewCustomConsistentHash(ring, redis.Hash, minReplicas)
+	assert.Nil(t, ch.AddCtx(context.Background(), "first"))
+
+	m.Close()
+	// redis 不可用时返回错误而不是 panic
+	assert.NotNil(t, ch.AddCtx(context.Background(), "second"))
+	assert.NotNil(t, ch.RemoveCtx(context.Background(), "first"))
+	_, ok, err := ch.GetCtx(context.Background(), "any")
+	assert.False(t, ok)
+	assert.NotNil(t, err)
+	_, ok = ch.Get("any")
+	assert.False(t, ok)
+	assert.Panics(t, func() {
+		ch.Add("second")
+	})
+
+	// ctx 已取消时直接返回
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NotNil(t, ch.AddCtx(ctx, "first"))
+
+	// 加锁失败不会遗留进程内的锁，redis 恢复后可以继续使用
+	// 连接池在多次拨号失败后会退避一段时间，需要等待恢复
+	assert.Nil(t, m.Restart())
+	assert.Eventually(t, func() bool {
+		return ch.AddCtx(context.Background(), "second") == nil
+	}, 3*time.Second, 100*time.Millisecond)
+	_, ok = ch.Get("any")
+	assert.True(t, ok)
+}
+
+func TestConsistentHash_GetN(t *testing.T) {
+	ch := NewConsistentHash()
+	assert.Empty(t, ch.GetN("key", 3))
+	for i := 0; i < 5; i++ {
+		ch.Add("localhost:" + strconv.Itoa(i))
+	}
+
+	nodes := ch.GetN("key", 3)
+	assert.Len(t, nodes, 3)
+	primary, _ := ch.Get("key")
+	assert.Equal(t, primary, nodes[0])
+
+	// 节点数不足时返回全部节点
+	assert.Len(t, ch.GetN("key", 10), 5)
+
+	// 跳过被摘除的节点
+	ch.MarkDown(nodes[1])
+	assert.NotContains(t, ch.GetN("key", 3), nodes[1])
+}
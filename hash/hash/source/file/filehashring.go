@@ -0,0 +1,318 @@
+//go:build unix
+
+package file
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-zero-source/hash/hash/source/local"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+)
+
+const (
+	// 获取文件锁失败后的重试间隔
+	lockRetryInterval = 10 * time.Millisecond
+	// 锁文件后缀
+	lockSuffix = ".lock"
+)
+
+// ErrNotLocked 未加锁时修改哈希环
+var ErrNotLocked = errors.New("file hash ring is not locked")
+
+type (
+	// FileHashRing 使用本地文件实现HashRing接口，同一台机器上的多个进程可以共享哈希环
+	// 1. 哈希环以按虚拟节点排序的 json 行存储在文件中，每行一个虚拟节点
+	// 2. 使用 flock 对锁文件加排他锁，Lock 时从文件加载最新的哈希环
+	// 3. AddNode、RemoveNode 只修改内存，Unlock 时原子地写回文件（写临时文件后 rename）
+	// 查询方法使用 Lock 时加载的哈希环，需要在 Lock 和 Unlock 之间调用
+	FileHashRing struct {
+		path string
+
+		// 本进程内的互斥锁，使用 channel 实现，等待时可以响应 ctx
+		sem      chan struct{}
+		lockFile *os.File
+
+		records map[recordKey]uint64 // 真实节点及序号到虚拟节点的映射
+		ring    *local.SliceHashRing // 查询使用的哈希环
+		loaded  os.FileInfo          // 已加载的文件，rename 写入后文件会变化，据此判断是否需要重新加载
+		dirty   bool                 // 内存中的哈希环是否有未写回的修改
+	}
+
+	recordKey struct {
+		node string
+		idx  int
+	}
+
+	// 文件中的一行
+	record struct {
+		VirtualNode uint64 `json:"virtualNode"`
+		Node        string `json:"node"`
+		Idx         int    `json:"idx"`
+	}
+)
+
+// NewFileHashRing 创建基于文件的哈希环，path 为存储哈希环的文件路径
+func NewFileHashRing(path string) *FileHashRing {
+	return &FileHashRing{
+		path:    path,
+		sem:     make(chan struct{}, 1),
+		records: make(map[recordKey]uint64),
+		ring:    local.NewSliceHashRing(),
+	}
+}
+
+// Lock 加锁，先获取进程内的互斥锁，再对锁文件加 flock 排他锁，获取成功后加载最新的哈希环
+func (f *FileHashRing) Lock(ctx context.Context) error {
+	select {
+	case f.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if err := f.lock(ctx); err != nil {
+		<-f.sem
+		return err
+	}
+
+	if err := f.load(); err != nil {
+		f.unlock()
+		<-f.sem
+		return err
+	}
+
+	return nil
+}
+
+// Unlock 写回修改后解锁，未加锁时返回 ErrNotLocked，不会释放进程内的互斥锁
+func (f *FileHashRing) Unlock() error {
+	if f.lockFile == nil {
+		return ErrNotLocked
+	}
+
+	defer func() {
+		<-f.sem
+	}()
+
+	var err error
+	if f.dirty {
+		// 写回失败时内存中的修改没有落盘，丢弃内存中的哈希环，下次加锁时从文件重新加载
+		if err = f.flush(); err != nil {
+			f.reset(nil)
+			f.dirty = false
+		}
+	}
+
+	if e := f.unlock(); e != nil && err == nil {
+		err = e
+	}
+
+	return err
+}
+
+// AddNode 添加虚拟节点
+func (f *FileHashRing) AddNode(ctx context.Context, node string, virtualNode uint64, idx int) error {
+	if f.lockFile == nil {
+		return ErrNotLocked
+	}
+
+	key := recordKey{node: node, idx: idx}
+	if _, ok := f.records[key]; ok {
+		return nil
+	}
+
+	f.records[key] = virtualNode
+	f.dirty = true
+	return f.ring.AddNode(ctx, node, virtualNode, idx)
+}
+
+// RemoveNode 删除虚拟节点
+func (f *FileHashRing) RemoveNode(ctx context.Context, node string, virtualNode uint64, idx int) error {
+	if f.lockFile == nil {
+		return ErrNotLocked
+	}
+
+	key := recordKey{node: node, idx: idx}
+	if _, ok := f.records[key]; !ok {
+		return nil
+	}
+
+	delete(f.records, key)
+	f.dirty = true
+	return f.ring.RemoveNode(ctx, node, virtualNode, idx)
+}
+
+// ContainsNode 检查真实节点是否存在
+func (f *FileHashRing) ContainsNode(ctx context.Context, node string) (bool, error) {
+	return f.ring.ContainsNode(ctx, node)
+}
+
+// GetNode 根据hash获取真实节点
+func (f *FileHashRing) GetNode(ctx context.Context, hash uint64) (string, bool, error) {
+	return f.ring.GetNode(ctx, hash)
+}
+
+// Walk 从 hash 开始顺时针遍历真实节点，fn 返回 false 时停止
+func (f *FileHashRing) Walk(ctx context.Context, hash uint64, fn func(virtualNode uint64, node string) bool) error {
+	return f.ring.Walk(ctx, hash, fn)
+}
+
+// 对锁文件加 flock 排他锁，被其他进程持有时间隔重试，直到 ctx 结束
+func (f *FileHashRing) lock(ctx context.Context) error {
+	file, err := os.OpenFile(f.path+lockSuffix, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return fmt.Errorf("file hash ring open lock file fail, err: %w", err)
+	}
+
+	for {
+		err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			f.lockFile = file
+			return nil
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			_ = file.Close()
+			return fmt.Errorf("file hash ring flock fail, err: %w", err)
+		}
+
+		select {
+		case <-time.After(lockRetryInterval):
+		case <-ctx.Done():
+			_ = file.Close()
+			return ctx.Err()
+		}
+	}
+}
+
+// 释放 flock 并关闭锁文件
+func (f *FileHashRing) unlock() error {
+	file := f.lockFile
+	f.lockFile = nil
+
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+	if e := file.Close(); e != nil && err == nil {
+		err = e
+	}
+
+	return err
+}
+
+// 从文件加载哈希环，文件未变化时直接使用内存中的哈希环
+func (f *FileHashRing) load() error {
+	f.dirty = false
+
+	info, err := os.Stat(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		f.reset(nil)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("file hash ring stat fail, err: %w", err)
+	}
+
+	if f.loaded != nil && os.SameFile(f.loaded, info) &&
+		f.loaded.ModTime().Equal(info.ModTime()) && f.loaded.Size() == info.Size() {
+		return nil
+	}
+
+	file, err := os.Open(f.path)
+	if err != nil {
+		return fmt.Errorf("file hash ring open fail, err: %w", err)
+	}
+	defer file.Close()
+
+	var records []record
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var r record
+		if err = json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return fmt.Errorf("file hash ring decode fail, err: %w", err)
+		}
+		records = append(records, r)
+	}
+	if err = scanner.Err(); err != nil {
+		return fmt.Errorf("file hash ring read fail, err: %w", err)
+	}
+
+	f.reset(records)
+	f.loaded = info
+
+	return nil
+}
+
+// 使用 records 重建内存中的哈希环
+func (f *FileHashRing) reset(records []record) {
+	f.records = make(map[recordKey]uint64, len(records))
+	f.ring = local.NewSliceHashRing()
+	f.loaded = nil
+
+	for _, r := range records {
+		key := recordKey{node: r.Node, idx: r.Idx}
+		if _, ok := f.records[key]; ok {
+			continue
+		}
+		f.records[key] = r.VirtualNode
+		_ = f.ring.AddNode(context.Background(), r.Node, r.VirtualNode, r.Idx)
+	}
+}
+
+// 将哈希环按虚拟节点排序后写入临时文件，再 rename 覆盖，保证其他进程不会读到写了一半的文件
+func (f *FileHashRing) flush() error {
+	records := make([]record, 0, len(f.records))
+	for key, virtualNode := range f.records {
+		records = append(records, record{VirtualNode: virtualNode, Node: key.node, Idx: key.idx})
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].VirtualNode != records[j].VirtualNode {
+			return records[i].VirtualNode < records[j].VirtualNode
+		}
+		if records[i].Node != records[j].Node {
+			return records[i].Node < records[j].Node
+		}
+		return records[i].Idx < records[j].Idx
+	})
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("file hash ring create temp fail, err: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for _, r := range records {
+		if err = encoder.Encode(r); err != nil {
+			_ = tmp.Close()
+			return fmt.Errorf("file hash ring encode fail, err: %w", err)
+		}
+	}
+	if err = writer.Flush(); err == nil {
+		err = tmp.Sync()
+	}
+	if e := tmp.Close(); e != nil && err == nil {
+		err = e
+	}
+	if err != nil {
+		return fmt.Errorf("file hash ring write fail, err: %w", err)
+	}
+
+	if err = os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("file hash ring rename fail, err: %w", err)
+	}
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return fmt.Errorf("file hash ring stat fail, err: %w", err)
+	}
+	f.loaded = info
+	f.dirty = false
+
+	return nil
+}
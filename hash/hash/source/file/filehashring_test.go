@@ -0,0 +1,121 @@
+//go:build unix
+
+package file
+
+import (
+	"context"
+	"go-zero-source/hash/hash/source"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileHashRing_Lock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ring")
+	ring := NewFileHashRing(path)
+	// 模拟另一个进程
+	other := NewFileHashRing(path)
+
+	assert.ErrorIs(t, ring.AddNode(context.Background(), "first", 100, 0), ErrNotLocked)
+
+	assert.Nil(t, ring.Lock(context.Background()))
+	ctx, cancel := context.WithTimeout(context.Background(), lockRetryInterval*3)
+	defer cancel()
+	assert.ErrorIs(t, other.Lock(ctx), context.DeadlineExceeded)
+
+	assert.Nil(t, ring.Unlock())
+	assert.Nil(t, other.Lock(context.Background()))
+	assert.Nil(t, other.Unlock())
+}
+
+func TestFileHashRing_Shared(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ring")
+	writer := source.NewCustomConsistentHash(NewFileHashRing(path), nil, 0)
+	reader := source.NewCustomConsistentHash(NewFileHashRing(path), nil, 0)
+
+	_, ok := reader.Get("any")
+	assert.False(t, ok)
+
+	assert.Nil(t, writer.AddCtx(context.Background(), "first"))
+	for i := 0; i < 100; i++ {
+		node, ok := reader.Get(strconv.Itoa(i))
+		assert.True(t, ok)
+		assert.Equal(t, "first", node)
+	}
+
+	// 另一个进程修改哈希环
+	assert.Nil(t, reader.AddCtx(context.Background(), "second"))
+	assert.Nil(t, reader.RemoveCtx(context.Background(), "first"))
+	for i := 0; i < 100; i++ {
+		node, ok := writer.Get(strconv.Itoa(i))
+		assert.True(t, ok)
+		assert.Equal(t, "second", node)
+	}
+}
+
+func TestFileHashRing_Concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ring")
+
+	const workers = 4
+	done := make(chan struct{})
+	for i := 0; i < workers; i++ {
+		go func(i int) {
+			defer func() { done <- struct{}{} }()
+			ch := source.NewCustomConsistentHash(NewFileHashRing(path), nil, 0)
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			assert.Nil(t, ch.AddCtx(ctx, "node"+strconv.Itoa(i)))
+		}(i)
+	}
+	for i := 0; i < workers; i++ {
+		<-done
+	}
+
+	// 并发写入互不覆盖
+	analysis := source.NewCustomConsistentHash(NewFileHashRing(path), nil, 0).Analyze()
+	assert.Len(t, analysis.Nodes, workers)
+}
+
+func TestFileHashRing_FlushFail(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "ring")
+	assert.Nil(t, os.Mkdir(dir, 0o755))
+	path := filepath.Join(dir, "ring")
+	ring := NewFileHashRing(path)
+
+	assert.Nil(t, ring.Lock(context.Background()))
+	assert.Nil(t, ring.AddNode(context.Background(), "first", 100, 0))
+	assert.Nil(t, ring.Unlock())
+
+	// 目录被移走，写回失败
+	assert.Nil(t, ring.Lock(context.Background()))
+	assert.Nil(t, ring.AddNode(context.Background(), "second", 200, 0))
+	assert.Nil(t, os.Rename(dir, dir+".bak"))
+	assert.NotNil(t, ring.Unlock())
+	assert.Nil(t, os.Rename(dir+".bak", dir))
+
+	// 未落盘的修改被丢弃，重新从文件加载
+	assert.Nil(t, ring.Lock(context.Background()))
+	ok, _ := ring.ContainsNode(context.Background(), "second")
+	assert.False(t, ok)
+	ok, _ = ring.ContainsNode(context.Background(), "first")
+	assert.True(t, ok)
+	assert.Nil(t, ring.Unlock())
+}
+
+func TestFileHashRing_UnlockWithoutLock(t *testing.T) {
+	ring := NewFileHashRing(filepath.Join(t.TempDir(), "ring"))
+
+	// 未加锁或重复解锁时返回错误，不能 panic，也不能阻塞
+	assert.ErrorIs(t, ring.Unlock(), ErrNotLocked)
+	assert.Nil(t, ring.Lock(context.Background()))
+	assert.Nil(t, ring.Unlock())
+	assert.ErrorIs(t, ring.Unlock(), ErrNotLocked)
+
+	// 不影响之后的加锁
+	assert.Nil(t, ring.Lock(context.Background()))
+	assert.Nil(t, ring.Unlock())
+}
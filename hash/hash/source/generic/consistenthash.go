@@ -0,0 +1,124 @@
+package generic
+
+import (
+	"context"
+	"go-zero-source/hash/hash/source"
+	"sync"
+
+	"github.com/zeromicro/go-zero/core/lang"
+)
+
+type (
+	// KeyFunc 返回真实节点在哈希环上的唯一标识
+	KeyFunc[N comparable] func(node N) string
+
+	// ConsistentHash 泛型一致性哈希，Get 直接返回类型化的真实节点
+	// 底层复用 source.ConsistentHash，哈希环中只存储节点标识，标识到节点的映射保存在本地
+	ConsistentHash[N comparable] struct {
+		hash    *source.ConsistentHash
+		keyFunc KeyFunc[N]
+		nodes   map[string]N // 节点标识到真实节点的映射
+		lock    sync.RWMutex
+	}
+)
+
+// NewConsistentHash 使用默认参数创建泛型一致性哈希实例
+func NewConsistentHash[N comparable](keyFunc KeyFunc[N]) *ConsistentHash[N] {
+	return NewCustomConsistentHash[N](nil, nil, 0, keyFunc)
+}
+
+// NewCustomConsistentHash 自定义参数创建泛型一致性哈希实例，hashRing 可以是任意 HashRing 实现
+// keyFunc 为空时使用节点的字符串表示作为标识
+func NewCustomConsistentHash[N comparable](hashRing source.HashRing, hashFunc source.HashFunc, replicas int,
+	keyFunc KeyFunc[N]) *ConsistentHash[N] {
+	if keyFunc == nil {
+		keyFunc = func(node N) string {
+			return lang.Repr(node)
+		}
+	}
+
+	return &ConsistentHash[N]{
+		hash:    source.NewCustomConsistentHash(hashRing, hashFunc, replicas),
+		keyFunc: keyFunc,
+		nodes:   make(map[string]N),
+	}
+}
+
+// Add 添加真实节点，标识相同的节点会被替换，哈希环出错时 panic
+func (h *ConsistentHash[N]) Add(node N) {
+	if err := h.AddCtx(context.Background(), node); err != nil {
+		panic(err)
+	}
+}
+
+// AddCtx 添加真实节点，标识相同的节点会被替换，哈希环出错时恢复原来的映射
+func (h *ConsistentHash[N]) AddCtx(ctx context.Context, node N) error {
+	key := h.keyFunc(node)
+
+	// 先写入映射，节点加入哈希环后即可查询到
+	h.lock.Lock()
+	old, existed := h.nodes[key]
+	h.nodes[key] = node
+	h.lock.Unlock()
+
+	if err := h.hash.AddCtx(ctx, key); err != nil {
+		h.lock.Lock()
+		if existed {
+			h.nodes[key] = old
+		} else {
+			delete(h.nodes, key)
+		}
+		h.lock.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// Remove 删除真实节点，哈希环出错时 panic
+func (h *ConsistentHash[N]) Remove(node N) {
+	if err := h.RemoveCtx(context.Background(), node); err != nil {
+		panic(err)
+	}
+}
+
+// RemoveCtx 删除真实节点
+func (h *ConsistentHash[N]) RemoveCtx(ctx context.Context, node N) error {
+	key := h.keyFunc(node)
+
+	if err := h.hash.RemoveCtx(ctx, key); err != nil {
+		return err
+	}
+
+	h.lock.Lock()
+	delete(h.nodes, key)
+	h.lock.Unlock()
+
+	return nil
+}
+
+// Get 查询 key 对应的真实节点，哈希环出错时返回 false
+func (h *ConsistentHash[N]) Get(key string) (N, bool) {
+	node, ok, _ := h.GetCtx(context.Background(), key)
+	return node, ok
+}
+
+// GetCtx 查询 key 对应的真实节点
+// 共享哈希环中由其他进程添加、本地未 Add 过的节点无法映射，返回 false
+func (h *ConsistentHash[N]) GetCtx(ctx context.Context, key string) (N, bool, error) {
+	var zero N
+
+	nodeKey, ok, err := h.hash.GetCtx(ctx, key)
+	if err != nil || !ok {
+		return zero, false, err
+	}
+
+	h.lock.RLock()
+	node, ok := h.nodes[nodeKey]
+	h.lock.RUnlock()
+	if !ok {
+		return zero, false, nil
+	}
+
+	return node, true, nil
+}
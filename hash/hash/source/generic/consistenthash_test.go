@@ -0,0 +1,81 @@
+package generic
+
+import (
+	"context"
+	"go-zero-source/hash/hash/source/redis"
+	"strconv"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type conn struct {
+	addr string
+}
+
+func TestConsistentHash(t *testing.T) {
+	ch := NewConsistentHash(func(c *conn) string { return c.addr })
+	_, ok := ch.Get("any")
+	assert.False(t, ok)
+
+	first := &conn{addr: "localhost:8080"}
+	second := &conn{addr: "localhost:8081"}
+	ch.Add(first)
+	for i := 0; i < 100; i++ {
+		node, ok := ch.Get(strconv.Itoa(i))
+		assert.True(t, ok)
+		assert.Same(t, first, node)
+	}
+
+	ch.Add(second)
+	ch.Remove(first)
+	for i := 0; i < 100; i++ {
+		node, ok := ch.Get(strconv.Itoa(i))
+		assert.True(t, ok)
+		assert.Same(t, second, node)
+	}
+}
+
+func TestConsistentHashDefaultKey(t *testing.T) {
+	ch := NewConsistentHash[int](nil)
+	for i := 0; i < 10; i++ {
+		ch.Add(i)
+	}
+
+	counts := make(map[int]int)
+	for i := 0; i < 1000; i++ {
+		node, ok := ch.Get(strconv.Itoa(i))
+		assert.True(t, ok)
+		counts[node]++
+	}
+	assert.Len(t, counts, 10)
+}
+
+func TestConsistentHashRedis(t *testing.T) {
+	m := miniredis.RunT(t)
+	ring := redis.NewZSetHashRing("hashRing", m.Addr(), "")
+	ch := NewCustomConsistentHash(ring, redis.Hash, 0, func(c *conn) string { return c.addr })
+
+	first := &conn{addr: "localhost:8080"}
+	ch.Add(first)
+	node, ok := ch.Get("any")
+	assert.True(t, ok)
+	assert.Same(t, first, node)
+}
+
+func TestConsistentHashAddFail(t *testing.T) {
+	m := miniredis.RunT(t)
+	ring := redis.NewZSetHashRing("hashRing", m.Addr(), "", redis.WithRetries(0))
+	ch := NewCustomConsistentHash(ring, redis.Hash, 0, func(c *conn) string { return c.addr })
+
+	first := &conn{addr: "localhost:8080"}
+	assert.Nil(t, ch.AddCtx(context.Background(), first))
+
+	// 哈希环出错时映射与哈希环保持一致：新节点不保留，同标识的节点恢复为原来的节点
+	m.Close()
+	assert.NotNil(t, ch.AddCtx(context.Background(), &conn{addr: "localhost:8081"}))
+	assert.NotNil(t, ch.AddCtx(context.Background(), &conn{addr: "localhost:8080"}))
+	assert.Len(t, ch.nodes, 1)
+	assert.Same(t, first, ch.nodes["localhost:8080"])
+}
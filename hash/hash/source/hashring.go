@@ -1,13 +1,47 @@
 package source
 
+import "context"
+
 // HashRing 哈希环接口
+// 远程实现（如 redis）需要在 ctx 结束时尽快返回，解锁不受 ctx 控制，保证锁一定被释放
 type HashRing interface {
-	Lock() error   // 加锁
-	Unlock() error // 解锁
+	Lock(ctx context.Context) error // 加锁
+	Unlock() error                  // 解锁
+
+	AddNode(ctx context.Context, node string, virtualNode uint64, idx int) error    // 添加节点
+	RemoveNode(ctx context.Context, node string, virtualNode uint64, idx int) error // 删除节点
+
+	ContainsNode(ctx context.Context, node string) (bool, error)    // 检查节点是否存在
+	GetNode(ctx context.Context, hash uint64) (string, bool, error) // 根据hash获取节点
+}
+
+// RWHashRing 支持读锁的哈希环，查询节点时只需加读锁
+type RWHashRing interface {
+	HashRing
+
+	RLock(ctx context.Context) error // 加读锁
+	RUnlock() error                  // 解读锁
+}
+
+// WalkHashRing 支持顺时针遍历的哈希环
+type WalkHashRing interface {
+	HashRing
+
+	// Walk 从 hash 开始顺时针遍历虚拟节点及其对应的真实节点，fn 返回 false 时停止，同一真实节点可能被遍历多次
+	Walk(ctx context.Context, hash uint64, fn func(virtualNode uint64, node string) bool) error
+}
 
-	AddNode(node string, virtualNode uint64, idx int) error    // 添加节点
-	RemoveNode(node string, virtualNode uint64, idx int) error // 删除节点
+// 查询加锁，支持读锁的哈希环只加读锁，返回解锁函数
+func readLock(ctx context.Context, hashRing HashRing) (func(), error) {
+	if rw, ok := hashRing.(RWHashRing); ok {
+		if err := rw.RLock(ctx); err != nil {
+			return nil, err
+		}
+		return func() { rw.RUnlock() }, nil
+	}
 
-	ContainsNode(node string) bool      // 检查节点是否存在
-	GetNode(hash uint64) (string, bool) // 根据hash获取节点
+	if err := hashRing.Lock(ctx); err != nil {
+		return nil, err
+	}
+	return func() { hashRing.Unlock() }, nil
 }
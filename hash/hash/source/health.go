@@ -0,0 +1,112 @@
+package source
+
+import (
+	"context"
+	"errors"
+	breaker "go-zero-source/breaker/go-zero/source"
+)
+
+// ErrNoAvailableNode 哈希环上没有可用的节点
+var ErrNoAvailableNode = errors.New("consistent hash: no available node")
+
+// MarkDown 临时摘除真实节点，节点仍在环上，Get 时跳过该节点，不会导致其他 key 重新分布
+func (h *ConsistentHash) MarkDown(node string) {
+	h.healthLock.Lock()
+	h.down[node] = struct{}{}
+	h.healthLock.Unlock()
+}
+
+// MarkUp 恢复被摘除的真实节点，原本属于该节点的 key 会重新回到该节点
+func (h *ConsistentHash) MarkUp(node string) {
+	h.healthLock.Lock()
+	delete(h.down, node)
+	h.healthLock.Unlock()
+}
+
+// IsHealthy 判断真实节点是否未被摘除
+func (h *ConsistentHash) IsHealthy(node string) bool {
+	h.healthLock.RLock()
+	defer h.healthLock.RUnlock()
+
+	_, ok := h.down[node]
+	return !ok
+}
+
+// Do 根据 key 选择节点执行 req，每个真实节点对应一个熔断器
+// 1. 从 key 所在位置开始顺时针遍历，跳过被摘除的节点和熔断器拒绝的节点
+// 2. 使用第一个被熔断器放行的节点执行 req，并将结果反馈给该节点的熔断器
+// 熔断器恢复放行后，key 自然回到原节点
+// 哈希环不支持遍历（未实现 WalkHashRing）时只能使用 key 所在的节点，无法转移到其他节点
+func (h *ConsistentHash) Do(key string, req func(node string) error) error {
+	node, promise, err := h.allow(key)
+	if err != nil {
+		return err
+	}
+
+	if err = req(node); err != nil {
+		promise.Reject(err.Error())
+	} else {
+		promise.Accept()
+	}
+
+	return err
+}
+
+// 找到第一个健康且被熔断器放行的节点
+func (h *ConsistentHash) allow(key string) (string, breaker.Promise, error) {
+	ctx := context.Background()
+	unlock, err := h.readLock(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	defer unlock()
+
+	var promise breaker.Promise
+	node, ok, err := h.next(ctx, h.hashFunc([]byte(key)), func(node string) bool {
+		if !h.IsHealthy(node) {
+			return false
+		}
+
+		p, err := h.getBreaker(node).Allow()
+		if err != nil {
+			return false
+		}
+		promise = p
+		return true
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	if !ok {
+		return "", nil, ErrNoAvailableNode
+	}
+
+	return node, promise, nil
+}
+
+// 获取真实节点对应的熔断器，不存在则创建
+func (h *ConsistentHash) getBreaker(node string) breaker.Breaker {
+	h.healthLock.RLock()
+	b, ok := h.breakers[node]
+	h.healthLock.RUnlock()
+	if ok {
+		return b
+	}
+
+	h.healthLock.Lock()
+	defer h.healthLock.Unlock()
+	if b, ok = h.breakers[node]; !ok {
+		b = breaker.NewBreaker(breaker.WithName(node))
+		h.breakers[node] = b
+	}
+
+	return b
+}
+
+// 清除真实节点的健康状态
+func (h *ConsistentHash) forget(node string) {
+	h.healthLock.Lock()
+	delete(h.down, node)
+	delete(h.breakers, node)
+	h.healthLock.Unlock()
+}
@@ -0,0 +1,351 @@
+package hotkey
+
+import (
+	"context"
+	"go-zero-source/hash/hash/source"
+	"go-zero-source/rollingwindow/rollingwindow"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSpread   = 3
+	defaultSize     = 10
+	defaultInterval = 100 * time.Millisecond
+	// 默认最多统计的 key 数量
+	defaultCapacity = 10000
+	// 分片数量，分散 Get 时的锁竞争
+	shardCount = 32
+	// 分片已满时随机抽样的 key 数量，淘汰其中请求数最少的
+	evictSamples = 5
+)
+
+type (
+	// Option 自定义 Router 的参数
+	Option func(r *Router)
+
+	// Router 热点 key 感知的路由
+	// 1. 按 key 分片统计请求数，新 key 先使用固定窗口计数，请求数超过阈值的一半后才升级为滑动窗口
+	// 2. 窗口内请求数超过阈值的 key 视为热点，在 key 顺时针方向的前 N 个节点间分散请求
+	// 3. 请求数降到阈值的一半以下时恢复为普通 key，路由回原节点
+	// 4. 统计的 key 数量有上限，分片已满时抽样淘汰请求数最少的非热点 key
+	Router struct {
+		hash       *source.ConsistentHash
+		threshold  int64 // 窗口内请求数超过该值视为热点
+		spread     int   // 热点 key 分散到的节点个数
+		roundRobin bool  // 热点 key 轮询选择节点，默认随机
+		capacity   int   // 最多统计的 key 数量
+
+		size     int           // 滑动窗口桶的数量
+		interval time.Duration // 滑动窗口桶的时间间隔
+
+		onHot  func(key string, count int64) // key 变为热点时回调
+		onCool func(key string)              // 热点 key 恢复时回调
+
+		shards [shardCount]*shard
+	}
+
+	// 一个分片内的 key 统计
+	shard struct {
+		keys      map[string]*keyStat
+		lastSweep time.Time
+		lock      sync.Mutex
+	}
+
+	// 单个 key 的统计
+	keyStat struct {
+		// 固定窗口计数，请求数较少时使用，避免为每个 key 创建滑动窗口
+		count int64
+		start time.Time
+		// 请求数超过阈值的一半后升级为滑动窗口
+		window *rollingwindow.Window
+		hot    bool
+		next   int // 轮询位置
+	}
+)
+
+// WithSpread 设置热点 key 分散到的节点个数
+func WithSpread(spread int) Option {
+	return func(r *Router) {
+		r.spread = spread
+	}
+}
+
+// WithRoundRobin 热点 key 在节点间轮询
+func WithRoundRobin() Option {
+	return func(r *Router) {
+		r.roundRobin = true
+	}
+}
+
+// WithWindow 设置统计请求数的滑动窗口，统计时长为 size * interval
+func WithWindow(size int, interval time.Duration) Option {
+	return func(r *Router) {
+		r.size = size
+		r.interval = interval
+	}
+}
+
+// WithCapacity 设置最多统计的 key 数量，默认 10000
+func WithCapacity(capacity int) Option {
+	return func(r *Router) {
+		r.capacity = capacity
+	}
+}
+
+// WithHotKeyHandler 设置 key 变为热点时的回调，count 为当前窗口内的请求数
+func WithHotKeyHandler(fn func(key string, count int64)) Option {
+	return func(r *Router) {
+		r.onHot = fn
+	}
+}
+
+// WithCoolKeyHandler 设置热点 key 恢复时的回调
+func WithCoolKeyHandler(fn func(key string)) Option {
+	return func(r *Router) {
+		r.onCool = fn
+	}
+}
+
+// NewRouter 创建热点 key 感知的路由，threshold 为窗口内判定为热点的请求数
+func NewRouter(hash *source.ConsistentHash, threshold int64, opts ...Option) *Router {
+	r := &Router{
+		hash:      hash,
+		threshold: threshold,
+		spread:    defaultSpread,
+		capacity:  defaultCapacity,
+		size:      defaultSize,
+		interval:  defaultInterval,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	now := time.Now()
+	for i := range r.shards {
+		r.shards[i] = &shard{
+			keys:      make(map[string]*keyStat),
+			lastSweep: now,
+		}
+	}
+
+	return r
+}
+
+// Get 统计 key 的请求并返回路由到的真实节点，哈希环出错时返回 false
+func (r *Router) Get(key string) (string, bool) {
+	node, ok, err := r.GetCtx(context.Background(), key)
+	if err != nil {
+		return "", false
+	}
+
+	return node, ok
+}
+
+// GetCtx 统计 key 的请求并返回路由到的真实节点，返回哈希环的错误
+func (r *Router) GetCtx(ctx context.Context, key string) (string, bool, error) {
+	hot, next := r.track(key)
+	if !hot {
+		return r.hash.GetCtx(ctx, key)
+	}
+
+	nodes, err := r.hash.GetNCtx(ctx, key, r.spread)
+	if err != nil || len(nodes) == 0 {
+		return "", false, err
+	}
+
+	if r.roundRobin {
+		return nodes[next%len(nodes)], true, nil
+	}
+
+	return nodes[rand.Intn(len(nodes))], true, nil
+}
+
+// HotKeys 返回当前的热点 key
+func (r *Router) HotKeys() []string {
+	var keys []string
+	for _, s := range r.shards {
+		s.lock.Lock()
+		for key, stat := range s.keys {
+			if stat.hot {
+				keys = append(keys, key)
+			}
+		}
+		s.lock.Unlock()
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// 记录一次请求，返回 key 是否为热点以及轮询位置
+func (r *Router) track(key string) (bool, int) {
+	var (
+		hotKeys  []string
+		coolKeys []string
+		count    int64
+	)
+
+	s := r.shard(key)
+	now := time.Now()
+
+	s.lock.Lock()
+	// 定期清理窗口内没有请求的 key，避免统计无限增长
+	if now.Sub(s.lastSweep) > r.period() {
+		coolKeys = s.sweep(now, r.period())
+	}
+
+	stat, ok := s.keys[key]
+	if !ok {
+		// 分片已满且无法淘汰时不统计，按普通 key 处理
+		if len(s.keys) >= r.shardCapacity() && !s.evict(now, r.period()) {
+			s.lock.Unlock()
+			r.notify(nil, coolKeys, 0)
+			return false, 0
+		}
+		stat = &keyStat{start: now}
+		s.keys[key] = stat
+	}
+
+	count = r.add(stat, now)
+	switch {
+	case !stat.hot && count > r.threshold:
+		stat.hot = true
+		hotKeys = append(hotKeys, key)
+	case stat.hot && count <= r.threshold/2:
+		// 降到阈值一半以下才恢复，避免在阈值附近反复切换
+		stat.hot = false
+		coolKeys = append(coolKeys, key)
+	}
+
+	hot, next := stat.hot, stat.next
+	if hot {
+		stat.next++
+	}
+	s.lock.Unlock()
+
+	r.notify(hotKeys, coolKeys, count)
+
+	return hot, next
+}
+
+// add 记录一次请求，返回窗口内的请求数，需要持有分片的锁
+func (r *Router) add(stat *keyStat, now time.Time) int64 {
+	if stat.window == nil {
+		if now.Sub(stat.start) > r.period() {
+			stat.count, stat.start = 0, now
+		}
+		stat.count++
+		if stat.count <= r.threshold/2 {
+			return stat.count
+		}
+
+		// 请求数较多，升级为滑动窗口，保留已有的计数
+		stat.window = rollingwindow.NewWindow(rollingwindow.WithSize(r.size), rollingwindow.WithInterval(r.interval))
+		stat.window.Add(stat.count)
+		return stat.count
+	}
+
+	stat.window.Add(1)
+	return stat.window.Reduce()
+}
+
+// notify 在锁外执行回调
+func (r *Router) notify(hotKeys, coolKeys []string, count int64) {
+	for _, key := range hotKeys {
+		if r.onHot != nil {
+			r.onHot(key, count)
+		}
+	}
+	for _, key := range coolKeys {
+		if r.onCool != nil {
+			r.onCool(key)
+		}
+	}
+}
+
+// shard 使用 FNV-1a 选择 key 所在的分片
+func (r *Router) shard(key string) *shard {
+	h := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= 16777619
+	}
+
+	return r.shards[h%shardCount]
+}
+
+// shardCapacity 单个分片最多统计的 key 数量
+func (r *Router) shardCapacity() int {
+	if n := r.capacity / shardCount; n > 0 {
+		return n
+	}
+
+	return 1
+}
+
+// period 统计窗口的时长
+func (r *Router) period() time.Duration {
+	return r.interval * time.Duration(r.size)
+}
+
+// 清理窗口内没有请求的 key，返回其中恢复的热点 key，需要持有锁
+func (s *shard) sweep(now time.Time, period time.Duration) []string {
+	var coolKeys []string
+	for key, stat := range s.keys {
+		if stat.requests(now, period) > 0 {
+			continue
+		}
+
+		if stat.hot {
+			coolKeys = append(coolKeys, key)
+		}
+		delete(s.keys, key)
+	}
+	s.lastSweep = now
+
+	return coolKeys
+}
+
+// evict 随机抽样若干个 key，淘汰其中请求数最少的非热点 key，没有可淘汰的 key 时返回 false，需要持有锁
+func (s *shard) evict(now time.Time, period time.Duration) bool {
+	var (
+		victim  string
+		min     int64
+		found   bool
+		sampled int
+	)
+	// map 的遍历顺序是随机的，前几个元素即为随机抽样
+	for key, stat := range s.keys {
+		if sampled >= evictSamples {
+			break
+		}
+		sampled++
+
+		if stat.hot {
+			continue
+		}
+		if n := stat.requests(now, period); !found || n < min {
+			victim, min, found = key, n, true
+		}
+	}
+	if found {
+		delete(s.keys, victim)
+	}
+
+	return found
+}
+
+// requests 窗口内的请求数
+func (k *keyStat) requests(now time.Time, period time.Duration) int64 {
+	if k.window != nil {
+		return k.window.Reduce()
+	}
+	if now.Sub(k.start) > period {
+		return 0
+	}
+
+	return k.count
+}
@@ -0,0 +1,94 @@
+package hotkey
+
+import (
+	"go-zero-source/hash/hash/source"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newHash() *source.ConsistentHash {
+	ch := source.NewConsistentHash()
+	for i := 0; i < 5; i++ {
+		ch.Add("localhost:" + strconv.Itoa(8080+i))
+	}
+	return ch
+}
+
+func TestRouter(t *testing.T) {
+	ch := newHash()
+	primary, _ := ch.Get("celebrity")
+
+	var hotKeys []string
+	r := NewRouter(ch, 10, WithRoundRobin(), WithSpread(3), WithHotKeyHandler(func(key string, count int64) {
+		hotKeys = append(hotKeys, key)
+	}))
+
+	counts := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		node, ok := r.Get("celebrity")
+		assert.True(t, ok)
+		counts[node]++
+
+		// 普通 key 不受影响
+		node, _ = r.Get("normal" + strconv.Itoa(i))
+		expect, _ := ch.Get("normal" + strconv.Itoa(i))
+		assert.Equal(t, expect, node)
+	}
+
+	assert.Equal(t, []string{"celebrity"}, hotKeys)
+	assert.Equal(t, []string{"celebrity"}, r.HotKeys())
+	// 前 10 次在原节点，之后在 3 个节点间轮询
+	assert.Len(t, counts, 3)
+	assert.True(t, counts[primary] > counts[ch.GetN("celebrity", 2)[1]])
+}
+
+func TestRouter_Cool(t *testing.T) {
+	const interval = 20 * time.Millisecond
+
+	ch := newHash()
+	primary, _ := ch.Get("celebrity")
+
+	var cooled []string
+	r := NewRouter(ch, 5, WithWindow(2, interval), WithCoolKeyHandler(func(key string) {
+		cooled = append(cooled, key)
+	}))
+
+	for i := 0; i < 10; i++ {
+		r.Get("celebrity")
+	}
+	assert.Equal(t, []string{"celebrity"}, r.HotKeys())
+
+	// 窗口过期后恢复到原节点
+	time.Sleep(interval * 3)
+	node, ok := r.Get("celebrity")
+	assert.True(t, ok)
+	assert.Equal(t, primary, node)
+	assert.Equal(t, []string{"celebrity"}, cooled)
+	assert.Empty(t, r.HotKeys())
+}
+
+func TestRouter_Capacity(t *testing.T) {
+	r := NewRouter(newHash(), 10, WithCapacity(64))
+
+	// 大量只访问一次的 key 不会让统计无限增长，热点 key 仍能被识别
+	for i := 0; i < 10000; i++ {
+		r.Get("celebrity")
+		r.Get("key:" + strconv.Itoa(i))
+	}
+	assert.Equal(t, []string{"celebrity"}, r.HotKeys())
+
+	var tracked int
+	for _, s := range r.shards {
+		tracked += len(s.keys)
+		// 只被访问一次的 key 不会创建滑动窗口
+		for key, stat := range s.keys {
+			if key != "celebrity" {
+				assert.Nil(t, stat.window)
+			}
+		}
+	}
+	assert.True(t, tracked <= 64)
+}
@@ -1,6 +1,7 @@
 package local
 
 import (
+	"context"
 	"math/rand"
 	"sort"
 	"sync"
@@ -23,7 +24,7 @@ func NewSliceHashRing() *SliceHashRing {
 }
 
 // Lock 加锁
-func (s *SliceHashRing) Lock() error {
+func (s *SliceHashRing) Lock(_ context.Context) error {
 	s.lock.Lock()
 
 	return nil
@@ -36,8 +37,22 @@ func (s *SliceHashRing) Unlock() error {
 	return nil
 }
 
+// RLock 加读锁
+func (s *SliceHashRing) RLock(_ context.Context) error {
+	s.lock.RLock()
+
+	return nil
+}
+
+// RUnlock 解读锁
+func (s *SliceHashRing) RUnlock() error {
+	s.lock.RUnlock()
+
+	return nil
+}
+
 // AddNode 添加真实节点、虚拟节点，建立虚拟节点到真实节点的映射
-func (s *SliceHashRing) AddNode(node string, virtualNode uint64, _ int) error {
+func (s *SliceHashRing) AddNode(_ context.Context, node string, virtualNode uint64, _ int) error {
 	// 添加真实节点
 	s.nodes[node] = struct{}{}
 
@@ -54,7 +69,7 @@ func (s *SliceHashRing) AddNode(node string, virtualNode uint64, _ int) error {
 }
 
 // RemoveNode 删除真实节点、虚拟节点，删除虚拟节点到真实节点的映射
-func (s *SliceHashRing) RemoveNode(node string, virtualNode uint64, _ int) error {
+func (s *SliceHashRing) RemoveNode(_ context.Context, node string, virtualNode uint64, _ int) error {
 	// 删除真实节点
 	delete(s.nodes, node)
 
@@ -89,16 +104,16 @@ func (s *SliceHashRing) RemoveNode(node string, virtualNode uint64, _ int) error
 }
 
 // ContainsNode 判断真实节点是否存在
-func (s *SliceHashRing) ContainsNode(node string) bool {
+func (s *SliceHashRing) ContainsNode(_ context.Context, node string) (bool, error) {
 	_, ok := s.nodes[node]
-	return ok
+	return ok, nil
 }
 
 // GetNode 根据虚拟节点获取真实节点
-func (s *SliceHashRing) GetNode(hash uint64) (string, bool) {
+func (s *SliceHashRing) GetNode(_ context.Context, hash uint64) (string, bool, error) {
 	// 哈希环为空，返回 nil
 	if len(s.keys) == 0 {
-		return "", false
+		return "", false, nil
 	}
 
 	// 找到第一个大于等于hash的虚拟节点（相当于顺时针）
@@ -107,13 +122,37 @@ func (s *SliceHashRing) GetNode(hash uint64) (string, bool) {
 	// 获取对应的真实节点列表 s.keys[idx]：虚拟节点
 	nodes, ok := s.ring[s.keys[idx]]
 	if !ok || len(nodes) == 0 {
-		return "", false
+		return "", false, nil
 	}
 
 	if len(nodes) == 1 {
-		return nodes[0], true
+		return nodes[0], true, nil
 	}
 
 	// 从列表中随机取出一个真实节点返回
-	return nodes[rand.Intn(len(nodes))], true
+	return nodes[rand.Intn(len(nodes))], true, nil
+}
+
+// Walk 从 hash 开始顺时针遍历真实节点，fn 返回 false 时停止
+func (s *SliceHashRing) Walk(_ context.Context, hash uint64, fn func(virtualNode uint64, node string) bool) error {
+	if len(s.keys) == 0 {
+		return nil
+	}
+
+	// 找到第一个大于等于hash的虚拟节点，从该位置开始绕环一圈
+	start := sort.Search(len(s.keys), func(i int) bool { return s.keys[i] >= hash })
+	for i := 0; i < len(s.keys); i++ {
+		virtualNode := s.keys[(start+i)%len(s.keys)]
+		// 发生哈希冲突时 keys 中存在重复的虚拟节点，对应的真实节点只遍历一次
+		if i > 0 && virtualNode == s.keys[(start+i-1)%len(s.keys)] {
+			continue
+		}
+		for _, node := range s.ring[virtualNode] {
+			if !fn(virtualNode, node) {
+				return nil
+			}
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,144 @@
+package source
+
+import (
+	"context"
+	"go-zero-source/hash/hash/source/local"
+	"strconv"
+)
+
+const (
+	// 默认探测次数，论文中 21 次探测时最大负载约为平均负载的 1.05 倍
+	defaultProbes = 21
+)
+
+// MultiProbeConsistentHash 多探测一致性哈希
+// https://arxiv.org/abs/1505.00062
+// 1. 每个真实节点在环上只有一个点，不需要虚拟节点，内存占用与节点数成正比
+// 2. 查询时对 key 计算 probes 个哈希值，每个哈希值顺时针找到最近的节点，取距离最小的节点
+// 探测次数越多越均衡，但查询越慢；远程哈希环上每次探测都是一次访问
+type MultiProbeConsistentHash struct {
+	hashRing WalkHashRing // 哈希环
+	hashFunc HashFunc     // 哈希函数
+	probes   int          // 每个 key 的探测次数
+}
+
+// NewMultiProbeConsistentHash 使用默认参数创建多探测一致性哈希实例
+func NewMultiProbeConsistentHash() *MultiProbeConsistentHash {
+	return NewCustomMultiProbeConsistentHash(local.NewSliceHashRing(), Hash, defaultProbes)
+}
+
+// NewCustomMultiProbeConsistentHash 自定义参数创建多探测一致性哈希实例
+func NewCustomMultiProbeConsistentHash(hashRing WalkHashRing, hashFunc HashFunc, probes int) *MultiProbeConsistentHash {
+	if hashRing == nil {
+		hashRing = local.NewSliceHashRing()
+	}
+
+	if hashFunc == nil {
+		hashFunc = Hash
+	}
+
+	if probes < 1 {
+		probes = defaultProbes
+	}
+
+	return &MultiProbeConsistentHash{
+		hashRing: hashRing,
+		hashFunc: hashFunc,
+		probes:   probes,
+	}
+}
+
+// Add 添加真实节点，哈希环出错时 panic，建议使用 AddCtx
+func (h *MultiProbeConsistentHash) Add(node string) {
+	if err := h.AddCtx(context.Background(), node); err != nil {
+		panic(err)
+	}
+}
+
+// AddCtx 添加真实节点，每个真实节点只在环上添加一个点
+func (h *MultiProbeConsistentHash) AddCtx(ctx context.Context, node string) (err error) {
+	// 支持重复添加
+	// 先删除该真实节点
+	if err = h.RemoveCtx(ctx, node); err != nil {
+		return err
+	}
+
+	if err = h.hashRing.Lock(ctx); err != nil {
+		return err
+	}
+	defer func() {
+		if e := h.hashRing.Unlock(); e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	return h.hashRing.AddNode(ctx, node, h.hashFunc([]byte(node)), 0)
+}
+
+// Remove 删除真实节点，哈希环出错时 panic，建议使用 RemoveCtx
+func (h *MultiProbeConsistentHash) Remove(node string) {
+	if err := h.RemoveCtx(context.Background(), node); err != nil {
+		panic(err)
+	}
+}
+
+// RemoveCtx 删除真实节点
+func (h *MultiProbeConsistentHash) RemoveCtx(ctx context.Context, node string) (err error) {
+	if err = h.hashRing.Lock(ctx); err != nil {
+		return err
+	}
+	defer func() {
+		if e := h.hashRing.Unlock(); e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	// 检查节点是否存在哈希环中，不存在直接返回
+	ok, err := h.hashRing.ContainsNode(ctx, node)
+	if err != nil || !ok {
+		return err
+	}
+
+	return h.hashRing.RemoveNode(ctx, node, h.hashFunc([]byte(node)), 0)
+}
+
+// Get 查询节点，哈希环出错时返回 false
+func (h *MultiProbeConsistentHash) Get(key string) (string, bool) {
+	node, ok, err := h.GetCtx(context.Background(), key)
+	if err != nil {
+		return "", false
+	}
+
+	return node, ok
+}
+
+// GetCtx 查询节点，返回距离 key 的各个探测点最近的真实节点
+func (h *MultiProbeConsistentHash) GetCtx(ctx context.Context, key string) (string, bool, error) {
+	unlock, err := readLock(ctx, h.hashRing)
+	if err != nil {
+		return "", false, err
+	}
+	defer unlock()
+
+	var (
+		found    string
+		distance uint64
+	)
+	for i := 0; i < h.probes; i++ {
+		// 计算第 i 个探测点
+		probe := h.hashFunc([]byte(key + strconv.Itoa(i)))
+
+		// 顺时针找到最近的节点，uint64 减法溢出正好得到绕环后的距离
+		err = h.hashRing.Walk(ctx, probe, func(virtualNode uint64, node string) bool {
+			if d := virtualNode - probe; len(found) == 0 || d < distance {
+				found, distance = node, d
+			}
+			return false
+		})
+		if err != nil {
+			return "", false, err
+		}
+	}
+
+	return found, len(found) > 0, nil
+}
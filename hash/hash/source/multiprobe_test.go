@@ -0,0 +1,152 @@
+package source
+
+import (
+	"context"
+	"go-zero-source/hash/hash/source/local"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	compareNodes = 100
+	sampleKeys   = 100000
+)
+
+// BenchmarkMultiProbeConsistentHashGet            282224              4883 ns/op
+func BenchmarkMultiProbeConsistentHashGet(b *testing.B) {
+	ch := NewMultiProbeConsistentHash()
+	for i := 0; i < keySize; i++ {
+		ch.Add("localhost:" + strconv.Itoa(i))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ch.Get(strconv.Itoa(i))
+	}
+}
+
+func TestMultiProbeConsistentHash(t *testing.T) {
+	ch := NewMultiProbeConsistentHash()
+	_, ok := ch.Get("any")
+	assert.False(t, ok)
+
+	ch.Add("first")
+	for i := 0; i < 100; i++ {
+		val, ok := ch.Get(strconv.Itoa(i))
+		assert.True(t, ok)
+		assert.Equal(t, "first", val)
+	}
+
+	ch.Add("second")
+	ch.Remove("first")
+	for i := 0; i < 100; i++ {
+		val, ok := ch.Get(strconv.Itoa(i))
+		assert.True(t, ok)
+		assert.Equal(t, "second", val)
+	}
+}
+
+func TestMultiProbeConsistentHashIncrementalTransfer(t *testing.T) {
+	ch := NewMultiProbeConsistentHash()
+	for i := 0; i < keySize; i++ {
+		ch.Add("localhost:" + strconv.Itoa(i))
+	}
+
+	origin := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		origin[key], _ = ch.Get(key)
+	}
+
+	// 删除节点只影响该节点上的 key
+	removed := "localhost:0"
+	ch.Remove(removed)
+	for key, node := range origin {
+		val, ok := ch.Get(key)
+		assert.True(t, ok)
+		if node == removed {
+			assert.NotEqual(t, removed, val)
+		} else {
+			assert.Equal(t, node, val)
+		}
+	}
+}
+
+// 与虚拟节点方式对比内存占用和均衡性
+func TestMultiProbeConsistentHashCompare(t *testing.T) {
+	var ring, probeRing *local.SliceHashRing
+	ringMem := heapInUse(func() {
+		ring = local.NewSliceHashRing()
+		ch := NewCustomConsistentHash(ring, nil, minReplicas)
+		for i := 0; i < compareNodes; i++ {
+			ch.Add("localhost:" + strconv.Itoa(i))
+		}
+	})
+	probeMem := heapInUse(func() {
+		probeRing = local.NewSliceHashRing()
+		ch := NewCustomMultiProbeConsistentHash(probeRing, nil, defaultProbes)
+		for i := 0; i < compareNodes; i++ {
+			ch.Add("localhost:" + strconv.Itoa(i))
+		}
+	})
+
+	assert.Equal(t, compareNodes*minReplicas, countPoints(ring))
+	assert.Equal(t, compareNodes, countPoints(probeRing))
+	assert.True(t, probeMem < ringMem)
+	t.Logf("memory: virtual nodes %d bytes, multi-probe %d bytes", ringMem, probeMem)
+
+	ringRatio := peakToMean(NewCustomConsistentHash(ring, nil, minReplicas).Get)
+	probeRatio := peakToMean(NewCustomMultiProbeConsistentHash(probeRing, nil, defaultProbes).Get)
+	assert.True(t, probeRatio < 1.5)
+	t.Logf("peak to mean: virtual nodes %.4f, multi-probe %.4f", ringRatio, probeRatio)
+
+	runtime.KeepAlive(ring)
+	runtime.KeepAlive(probeRing)
+}
+
+// 返回 fn 执行后新增的堆内存
+func heapInUse(fn func()) uint64 {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	fn()
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	if after.HeapAlloc < before.HeapAlloc {
+		return 0
+	}
+
+	return after.HeapAlloc - before.HeapAlloc
+}
+
+// 返回哈希环上点的数量
+func countPoints(ring *local.SliceHashRing) int {
+	var count int
+	_ = ring.Walk(context.Background(), 0, func(uint64, string) bool {
+		count++
+		return true
+	})
+
+	return count
+}
+
+// 返回最大负载与平均负载的比值
+func peakToMean(get func(key string) (string, bool)) float64 {
+	counts := make(map[string]int)
+	for i := 0; i < sampleKeys; i++ {
+		node, _ := get(strconv.Itoa(i))
+		counts[node]++
+	}
+
+	var peak int
+	for _, count := range counts {
+		if count > peak {
+			peak = count
+		}
+	}
+
+	return float64(peak) / (float64(sampleKeys) / float64(len(counts)))
+}
@@ -0,0 +1,201 @@
+package redis
+
+import (
+	"context"
+	"go-zero-source/hash/hash/source/local"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRefreshInterval = time.Minute
+)
+
+// CachedHashRing 带本地缓存的 redis 哈希环
+// 写操作直接写入 redis，读操作只访问本地缓存，本地缓存通过订阅变更事件和定期全量刷新与 redis 保持一致
+type CachedHashRing struct {
+	ring            *ZSetHashRing
+	refreshInterval time.Duration // 全量刷新间隔，兜底 pub/sub 丢失的消息
+
+	local   *local.SliceHashRing // 本地缓存的哈希环
+	members map[string]struct{}  // 本地缓存中已存在的虚拟节点，保证事件重复应用时幂等
+	lock    sync.RWMutex
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewCachedHashRing 创建带本地缓存的哈希环，返回前已完成首次全量加载
+func NewCachedHashRing(ring *ZSetHashRing, refreshInterval time.Duration) (*CachedHashRing, error) {
+	if refreshInterval <= 0 {
+		refreshInterval = defaultRefreshInterval
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &CachedHashRing{
+		ring:            ring,
+		refreshInterval: refreshInterval,
+		local:           local.NewSliceHashRing(),
+		members:         make(map[string]struct{}),
+		cancel:          cancel,
+		done:            make(chan struct{}),
+	}
+
+	// 先订阅再加载，避免加载期间的变更丢失
+	events := ring.Watch(ctx)
+	if err := c.reload(ctx); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	go c.watch(ctx, events)
+
+	return c, nil
+}
+
+// Close 停止订阅
+func (c *CachedHashRing) Close() {
+	c.cancel()
+	<-c.done
+}
+
+// Lock 加分布式锁
+func (c *CachedHashRing) Lock(ctx context.Context) error {
+	return c.ring.Lock(ctx)
+}
+
+// Unlock 解分布式锁
+func (c *CachedHashRing) Unlock() error {
+	return c.ring.Unlock()
+}
+
+// RLock 本地缓存内部已加锁，查询无需分布式锁
+func (c *CachedHashRing) RLock(_ context.Context) error {
+	return nil
+}
+
+// RUnlock 本地缓存内部已加锁，查询无需分布式锁
+func (c *CachedHashRing) RUnlock() error {
+	return nil
+}
+
+// AddNode 写入 redis 后同步更新本地缓存
+func (c *CachedHashRing) AddNode(ctx context.Context, node string, virtualNode uint64, idx int) error {
+	if err := c.ring.AddNode(ctx, node, virtualNode, idx); err != nil {
+		return err
+	}
+
+	c.apply(RingEvent{Type: NodeAdded, Node: node, VirtualNode: virtualNode, Idx: idx})
+	return nil
+}
+
+// RemoveNode 从 redis 删除后同步更新本地缓存
+func (c *CachedHashRing) RemoveNode(ctx context.Context, node string, virtualNode uint64, idx int) error {
+	if err := c.ring.RemoveNode(ctx, node, virtualNode, idx); err != nil {
+		return err
+	}
+
+	c.apply(RingEvent{Type: NodeRemoved, Node: node, VirtualNode: virtualNode, Idx: idx})
+	return nil
+}
+
+// ContainsNode 从本地缓存检查节点是否存在
+func (c *CachedHashRing) ContainsNode(ctx context.Context, node string) (bool, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.local.ContainsNode(ctx, node)
+}
+
+// GetNode 从本地缓存获取节点
+func (c *CachedHashRing) GetNode(ctx context.Context, hash uint64) (string, bool, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.local.GetNode(ctx, hash)
+}
+
+// Walk 从本地缓存顺时针遍历真实节点，fn 中不能再调用 CachedHashRing 的方法
+func (c *CachedHashRing) Walk(ctx context.Context, hash uint64, fn func(virtualNode uint64, node string) bool) error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.local.Walk(ctx, hash, fn)
+}
+
+// watch 消费变更事件，并定期全量刷新，订阅断开时在下次刷新时重新订阅
+func (c *CachedHashRing) watch(ctx context.Context, events <-chan RingEvent) {
+	defer close(c.done)
+
+	ticker := time.NewTicker(c.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if events == nil {
+				events = c.ring.Watch(ctx)
+			}
+			_ = c.reload(ctx)
+		case event, ok := <-events:
+			if !ok {
+				// 订阅已断开，等待下次刷新时重新订阅
+				events = nil
+				continue
+			}
+			c.apply(event)
+		}
+	}
+}
+
+// reload 从 redis 全量加载哈希环，替换本地缓存
+func (c *CachedHashRing) reload(ctx context.Context) error {
+	entries, err := c.ring.Members(ctx)
+	if err != nil {
+		return err
+	}
+
+	ring := local.NewSliceHashRing()
+	members := make(map[string]struct{}, len(entries))
+	for _, entry := range entries {
+		rawNodeKey := c.ring.getRawNodeKey(entry.Node, entry.Idx)
+		if _, ok := members[rawNodeKey]; ok {
+			continue
+		}
+		members[rawNodeKey] = struct{}{}
+		_ = ring.AddNode(ctx, entry.Node, entry.VirtualNode, entry.Idx)
+	}
+
+	c.lock.Lock()
+	c.local = ring
+	c.members = members
+	c.lock.Unlock()
+
+	return nil
+}
+
+// apply 将变更事件应用到本地缓存，重复的事件不会产生影响
+func (c *CachedHashRing) apply(event RingEvent) {
+	rawNodeKey := c.ring.getRawNodeKey(event.Node, event.Idx)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	_, exists := c.members[rawNodeKey]
+	switch event.Type {
+	case NodeAdded:
+		if exists {
+			return
+		}
+		c.members[rawNodeKey] = struct{}{}
+		_ = c.local.AddNode(context.Background(), event.Node, event.VirtualNode, event.Idx)
+	case NodeRemoved:
+		if !exists {
+			return
+		}
+		delete(c.members, rawNodeKey)
+		_ = c.local.RemoveNode(context.Background(), event.Node, event.VirtualNode, event.Idx)
+	}
+}
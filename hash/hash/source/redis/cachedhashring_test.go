@@ -0,0 +1,103 @@
+package redis
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZSetHashRing_Watch(t *testing.T) {
+	ring, _ := newTestRing(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := ring.Watch(ctx)
+
+	assert.Nil(t, ring.AddNode(bg, "first", 100, 0))
+	// 重复添加不产生事件
+	assert.Nil(t, ring.AddNode(bg, "first", 100, 0))
+	assert.Nil(t, ring.RemoveNode(bg, "first", 100, 0))
+
+	assert.Equal(t, RingEvent{Type: NodeAdded, Node: "first", VirtualNode: 100, Idx: 0}, <-events)
+	assert.Equal(t, RingEvent{Type: NodeRemoved, Node: "first", VirtualNode: 100, Idx: 0}, <-events)
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestCachedHashRing(t *testing.T) {
+	writer, m := newTestRing(t)
+	assert.Nil(t, writer.AddNode(bg, "first", 100, 0))
+
+	cached, err := NewCachedHashRing(NewZSetHashRing("hashRing", m.Addr(), ""), time.Minute)
+	assert.Nil(t, err)
+	defer cached.Close()
+
+	// 注意：NewZSetHashRing 会清空哈希环，重新写入
+	assert.Nil(t, writer.AddNode(bg, "first", 100, 0))
+	assert.Nil(t, writer.AddNode(bg, "second", 200, 1))
+
+	assert.Eventually(t, func() bool {
+		node, ok, _ := cached.GetNode(bg, 150)
+		return ok && node == "second"
+	}, time.Second, 10*time.Millisecond)
+	assert.True(t, contains(t, cached, "first"))
+
+	assert.Nil(t, writer.RemoveNode(bg, "second", 200, 1))
+	assert.Eventually(t, func() bool {
+		node, ok, _ := cached.GetNode(bg, 150)
+		return ok && node == "first"
+	}, time.Second, 10*time.Millisecond)
+
+	// 自身的写入立即可见
+	assert.Nil(t, cached.AddNode(bg, "third", 300, 0))
+	node, ok, _ := cached.GetNode(bg, 250)
+	assert.True(t, ok)
+	assert.Equal(t, "third", node)
+}
+
+func TestCachedHashRing_Reload(t *testing.T) {
+	ring, m := newTestRing(t)
+	cached, err := NewCachedHashRing(ring, 50*time.Millisecond)
+	assert.Nil(t, err)
+	defer cached.Close()
+
+	// 绕过事件通知直接写入，依赖全量刷新
+	_, err = m.ZAdd("hashRing", 100, `["first-0"]`)
+	assert.Nil(t, err)
+
+	assert.Eventually(t, func() bool {
+		node, ok, _ := cached.GetNode(bg, 50)
+		return ok && node == "first"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestCachedHashRing_RemoveAfterReload(t *testing.T) {
+	ring, _ := newTestRing(t)
+	// 超过 2^53 的虚拟节点，redis 的 score 会丢失精度
+	virtualNode := uint64(math.MaxUint64 - 1000)
+	assert.NotEqual(t, virtualNode, uint64(float64(virtualNode)))
+	assert.Nil(t, ring.AddNode(bg, "a", virtualNode, 0))
+
+	// 遍历和全量加载返回的都是精确值
+	var walked []uint64
+	assert.Nil(t, ring.Walk(bg, 0, func(v uint64, _ string) bool {
+		walked = append(walked, v)
+		return true
+	}))
+	assert.Equal(t, []uint64{virtualNode}, walked)
+
+	cached, err := NewCachedHashRing(ring, time.Minute)
+	assert.Nil(t, err)
+	defer cached.Close()
+
+	assert.Nil(t, cached.RemoveNode(bg, "a", virtualNode, 0))
+	_, ok, _ := ring.GetNode(bg, 0)
+	assert.False(t, ok)
+	_, ok, _ = cached.GetNode(bg, 0)
+	assert.False(t, ok)
+	assert.False(t, contains(t, cached, "a"))
+}
@@ -0,0 +1,163 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// NodeAdded 添加了虚拟节点
+	NodeAdded RingEventType = iota + 1
+	// NodeRemoved 删除了虚拟节点
+	NodeRemoved
+)
+
+type (
+	// RingEventType 哈希环变更类型
+	RingEventType int
+
+	// RingEvent 哈希环变更事件，粒度为虚拟节点
+	RingEvent struct {
+		Type        RingEventType `json:"type"`
+		Node        string        `json:"node"`        // 真实节点
+		VirtualNode uint64        `json:"virtualNode"` // 虚拟节点
+		Idx         int           `json:"idx"`         // 虚拟节点序号
+	}
+)
+
+func (t RingEventType) String() string {
+	switch t {
+	case NodeAdded:
+		return "added"
+	case NodeRemoved:
+		return "removed"
+	default:
+		return "unknown"
+	}
+}
+
+// Watch 订阅哈希环的变更事件，ctx 结束时关闭返回的 channel
+// 返回前已确认订阅成功，之后发生的变更都会被收到；订阅失败时返回已关闭的 channel
+// pub/sub 不保证送达，需要可靠视图的调用方应配合 Members 定期全量刷新
+func (z *ZSetHashRing) Watch(ctx context.Context) <-chan RingEvent {
+	events := make(chan RingEvent)
+
+	pubsub := z.client.Subscribe(ctx, z.getEventChannel())
+	// 等待订阅确认
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		close(events)
+		return events
+	}
+
+	go func() {
+		defer close(events)
+		defer pubsub.Close()
+
+		ch := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+
+				var event RingEvent
+				if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+					continue
+				}
+
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events
+}
+
+// Members 返回哈希环中全部的虚拟节点，用于订阅方全量加载
+// score 会丢失超过 2^53 的精度，虚拟节点使用单独存放的精确值，与事件中的虚拟节点保持一致
+func (z *ZSetHashRing) Members(ctx context.Context) ([]RingEvent, error) {
+	var (
+		entries      []redis.Z
+		virtualNodes map[string]string
+	)
+	// 在同一个事务中读取哈希环和虚拟节点的精确值，保证两者一致
+	err := z.do(ctx, func(ctx context.Context) error {
+		var (
+			zrange  *redis.ZSliceCmd
+			hgetall *redis.StringStringMapCmd
+		)
+		_, err := z.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			zrange = pipe.ZRangeWithScores(ctx, z.key, 0, -1)
+			hgetall = pipe.HGetAll(ctx, z.getVirtualNodesKey())
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		entries, virtualNodes = zrange.Val(), hgetall.Val()
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("redis hash members fail, err: %w", err)
+	}
+
+	var members []RingEvent
+	for _, entry := range entries {
+		raw, ok := entry.Member.(string)
+		if !ok {
+			continue
+		}
+
+		for _, rawNodeKey := range z.UnmarshalEntries([]string{raw}) {
+			idx, err := strconv.Atoi(rawNodeKey[strings.LastIndex(rawNodeKey, "-")+1:])
+			if err != nil {
+				continue
+			}
+
+			virtualNode, err := strconv.ParseUint(virtualNodes[rawNodeKey], 10, 64)
+			if err != nil {
+				virtualNode = uint64(entry.Score)
+			}
+
+			members = append(members, RingEvent{
+				Type:        NodeAdded,
+				Node:        z.getRawNode(rawNodeKey),
+				VirtualNode: virtualNode,
+				Idx:         idx,
+			})
+		}
+	}
+
+	return members, nil
+}
+
+// publish 发布哈希环变更事件
+// 发布失败不影响哈希环本身，订阅方通过定期全量刷新兜底
+func (z *ZSetHashRing) publish(event RingEvent) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	_ = z.do(context.Background(), func(ctx context.Context) error {
+		return z.client.Publish(ctx, z.getEventChannel(), payload).Err()
+	})
+}
+
+func (z *ZSetHashRing) getEventChannel() string {
+	return fmt.Sprintf("redis:consistent_hash:ring:event:%s", z.key)
+}
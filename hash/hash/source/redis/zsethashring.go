@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/go-redis/redis/v8"
+	"github.com/zeromicro/go-zero/core/stringx"
 	"math/rand"
 	"strconv"
 	"strings"
@@ -14,165 +15,478 @@ import (
 
 const (
 	defaultExpireSecond = 5 * time.Second
+	// 获取锁失败后的重试间隔
+	lockRetryInterval = 50 * time.Millisecond
+	// 获取锁的最长等待时间，超过则放弃
+	lockAcquireTimeout = 10 * time.Second
+	// 锁 value 的随机串长度
+	lockTokenLen = 16
+	// 顺时针遍历时每次从 redis 读取的元素个数
+	walkBatchSize = 64
+	// 单次 redis 操作的默认超时时间
+	defaultTimeout = time.Second
+	// 默认失败重试次数
+	defaultRetries = 2
+	// 重试间隔，第 n 次重试等待 n 倍间隔
+	retryBackoff = 50 * time.Millisecond
 )
 
-// ZSetHashRing 使用zset实现HashRing接口
-type ZSetHashRing struct {
-	// redis 存储哈希环的 key
-	key    string
-	client *redis.Client
+var (
+	// ErrLockTimeout 等待分布式锁超时
+	ErrLockTimeout = errors.New("redis hash ring acquire lock timeout")
+	// ErrLockNotHeld 解锁时发现锁已不属于自己（过期或被他人持有）
+	ErrLockNotHeld = errors.New("redis hash ring lock not held")
+
+	// 加锁，锁已经是自己的（上一次尝试在服务端成功但客户端超时）时同样返回成功，保证重试幂等
+	// KEYS[1]: 锁 key，ARGV[1]: token，ARGV[2]: 过期时间（毫秒）
+	lockScript = redis.NewScript(`
+local value = redis.call("GET", KEYS[1])
+if value == ARGV[1] then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	return 1
+end
+if value then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+return 1
+`)
+
+	// 仅当 value 与 token 一致时才删除锁，避免误删他人的锁
+	unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+	// 仅当 value 与 token 一致时才续期
+	renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+	// 添加虚拟节点，合并同一 score 下的冲突节点
+	// score 是 double，超过 2^53 的虚拟节点会丢失精度，精确值另外存放在 KEYS[2] 中
+	// KEYS[1]: 哈希环 key，KEYS[2]: 虚拟节点精确值 key，ARGV[1]: score，ARGV[2]: 真实节点key
+	addNodeScript = redis.NewScript(`
+local entries = redis.call("ZRANGEBYSCORE", KEYS[1], ARGV[1], ARGV[1])
+if #entries > 1 then
+	return redis.error_reply("invalid score entity len: " .. #entries)
+end
+local members = {}
+if #entries == 1 then
+	members = cjson.decode(entries[1])
+	for _, member in ipairs(members) do
+		if member == ARGV[2] then
+			return 0
+		end
+	end
+	redis.call("ZREMRANGEBYSCORE", KEYS[1], ARGV[1], ARGV[1])
+end
+table.insert(members, ARGV[2])
+redis.call("ZADD", KEYS[1], ARGV[1], cjson.encode(members))
+redis.call("HSET", KEYS[2], ARGV[2], ARGV[1])
+return 1
+`)
+
+	// 删除虚拟节点，同一 score 下还有其他节点时保留其余节点
+	// KEYS[1]: 哈希环 key，KEYS[2]: 虚拟节点精确值 key，ARGV[1]: score，ARGV[2]: 真实节点key
+	removeNodeScript = redis.NewScript(`
+local entries = redis.call("ZRANGEBYSCORE", KEYS[1], ARGV[1], ARGV[1])
+if #entries == 0 then
+	return 0
+end
+if #entries > 1 then
+	return redis.error_reply("invalid score entity len: " .. #entries)
+end
+local members = cjson.decode(entries[1])
+local rest = {}
+local found = false
+for _, member in ipairs(members) do
+	if not found and member == ARGV[2] then
+		found = true
+	else
+		table.insert(rest, member)
+	end
+end
+if not found then
+	return 0
+end
+redis.call("ZREMRANGEBYSCORE", KEYS[1], ARGV[1], ARGV[1])
+if #rest > 0 then
+	redis.call("ZADD", KEYS[1], ARGV[1], cjson.encode(rest))
+end
+redis.call("HDEL", KEYS[2], ARGV[2])
+return 1
+`)
+
+	// 检查真实节点是否存在，member 在 lua 中编码，与 addNodeScript 保持一致
+	containsNodeScript = redis.NewScript(`
+return redis.call("ZSCORE", KEYS[1], cjson.encode({ARGV[1]}))
+`)
+)
+
+type (
+	// ZSetOption 自定义 ZSetHashRing 的参数
+	ZSetOption func(z *ZSetHashRing)
+
+	// ZSetHashRing 使用zset实现HashRing接口
+	ZSetHashRing struct {
+		// redis 存储哈希环的 key
+		key    string
+		client *redis.Client
+
+		timeout time.Duration // 单次 redis 操作超时时间
+		retries int           // 网络错误、超时等失败的重试次数
+
+		// 本进程内的互斥锁，保证同一时刻只有一个协程持有分布式锁
+		// 使用 channel 实现，等待时可以响应 ctx
+		sem chan struct{}
+		// 当前持有的锁 value
+		token string
+		// 关闭续期协程
+		stopRenew chan struct{}
+		// 等待续期协程退出
+		renewDone chan struct{}
+	}
+)
+
+// WithTimeout 设置单次 redis 操作的超时时间
+func WithTimeout(timeout time.Duration) ZSetOption {
+	return func(z *ZSetHashRing) {
+		z.timeout = timeout
+	}
+}
+
+// WithRetries 设置失败重试次数，为 0 时不重试
+func WithRetries(retries int) ZSetOption {
+	return func(z *ZSetHashRing) {
+		z.retries = retries
+	}
 }
 
-func NewZSetHashRing(key, addr, passwd string) *ZSetHashRing {
+func NewZSetHashRing(key, addr, passwd string, opts ...ZSetOption) *ZSetHashRing {
 	client := redis.NewClient(&redis.Options{Addr: addr, Password: passwd})
 
+	z := &ZSetHashRing{
+		key:     key,
+		client:  client,
+		timeout: defaultTimeout,
+		retries: defaultRetries,
+		sem:     make(chan struct{}, 1),
+	}
+	for _, opt := range opts {
+		opt(z)
+	}
+
 	// 删除 key
-	_ = client.Del(context.Background(), key).Err()
+	_ = z.do(context.Background(), func(ctx context.Context) error {
+		return client.Del(ctx, key, z.getVirtualNodesKey()).Err()
+	})
 
-	return &ZSetHashRing{
-		key:    key,
-		client: client,
-	}
+	return z
 }
 
 func (z *ZSetHashRing) getLockKey() string {
 	return fmt.Sprintf("redis:consistent_hash:ring:lock:%s", z.key)
 }
 
-func (z *ZSetHashRing) Lock() error {
-	// 使用 setnx 简单加锁
-	return z.client.SetNX(context.Background(), z.getLockKey(), "", defaultExpireSecond).Err()
-}
-
-func (z *ZSetHashRing) Unlock() error {
-	// 直接删锁
-	return z.client.Del(context.Background(), z.getLockKey()).Err()
+// getVirtualNodesKey 存放虚拟节点精确值的 hash，field 为真实节点key，value 为虚拟节点
+func (z *ZSetHashRing) getVirtualNodesKey() string {
+	return fmt.Sprintf("redis:consistent_hash:ring:vnodes:%s", z.key)
 }
 
-// AddNode 添加节点
-// 相同 score 可能存在多个节点（发生hash冲突），如果冲突了需要合并放到一个元素中
-func (z *ZSetHashRing) AddNode(node string, virtualNode uint64, idx int) error {
-	score := strconv.FormatUint(virtualNode, 10)
-	rawNodeKey := z.getRawNodeKey(node, idx)
-
-	// 查询score位置是否存在member
-	entities, err := z.client.ZRangeByScore(context.Background(), z.key, &redis.ZRangeBy{Min: score, Max: score}).Result()
-	if err != nil && !errors.Is(err, redis.Nil) {
-		return fmt.Errorf("redis hash add fail, err:%w", err)
+// Lock 加锁
+// 1. 先获取进程内的互斥锁，再使用 lua 脚本 + 唯一 token 获取分布式锁，重试时幂等
+// 2. 获取失败时间隔重试，直到 ctx 结束或超过 lockAcquireTimeout
+// 3. 获取成功后启动续期协程，避免持锁期间锁过期
+func (z *ZSetHashRing) Lock(ctx context.Context) error {
+	select {
+	case z.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 
-	if len(entities) > 1 {
-		return fmt.Errorf("invalid score entity len: %d", len(entities))
-	}
+	token := stringx.Randn(lockTokenLen)
+	deadline := time.Now().Add(lockAcquireTimeout)
+	for {
+		var n int
+		err := z.do(ctx, func(ctx context.Context) (err error) {
+			n, err = lockScript.Run(ctx, z.client, []string{z.getLockKey()},
+				token, defaultExpireSecond.Milliseconds()).Int()
+			return
+		})
+		if err != nil {
+			<-z.sem
+			return fmt.Errorf("redis hash ring lock fail, err: %w", err)
+		}
+		if n == 1 {
+			break
+		}
 
-	var members []string
-	// 存在冲突，把已经存在的节点删掉
-	if len(entities) == 1 {
-		// 先反序列化为切片
-		members = z.UnmarshalEntries(entities)
-
-		// 已有该节点，返回
-		for _, member := range members {
-			if member == rawNodeKey {
-				return nil
-			}
+		if time.Now().After(deadline) {
+			<-z.sem
+			return ErrLockTimeout
 		}
 
-		// 删除当前节点
-		err = z.client.ZRemRangeByScore(context.Background(), z.key, score, score).Err()
-		if err != nil {
-			return fmt.Errorf("redis ring add fail, err: %w", err)
+		select {
+		case <-time.After(lockRetryInterval):
+		case <-ctx.Done():
+			<-z.sem
+			return ctx.Err()
 		}
 	}
 
-	// 追加最新节点
-	members = append(members, rawNodeKey)
-	entitiesBytes := z.MarshalEntries(members)
-	return z.client.ZAdd(context.Background(), z.key, &redis.Z{Score: float64(virtualNode), Member: entitiesBytes}).Err()
+	z.token = token
+	z.stopRenew = make(chan struct{})
+	z.renewDone = make(chan struct{})
+	go z.renew(token, z.stopRenew, z.renewDone)
+
+	return nil
 }
 
-// RemoveNode 删除节点
-// 相同 score 可能存在多个节点，此时需要先找到要删除的节点，再进行删除其中一个
-func (z *ZSetHashRing) RemoveNode(node string, virtualNode uint64, idx int) error {
-	score := strconv.FormatUint(virtualNode, 10)
-	rawNodeKey := z.getRawNodeKey(node, idx)
+// Unlock 解锁，停止续期，并使用 lua 比较 token 后删除锁
+// 未持有锁时返回 ErrLockNotHeld，不会释放进程内的互斥锁
+func (z *ZSetHashRing) Unlock() error {
+	if len(z.token) == 0 {
+		return ErrLockNotHeld
+	}
+
+	defer func() {
+		<-z.sem
+	}()
 
-	// 查询score位置是否存在member（寻找虚拟节点）
-	entities, err := z.client.ZRangeByScore(context.Background(), z.key, &redis.ZRangeBy{Min: score, Max: score}).Result()
-	// 没找到也算error
+	close(z.stopRenew)
+	<-z.renewDone
+
+	token := z.token
+	z.token = ""
+
+	var n, attempts int
+	err := z.do(context.Background(), func(ctx context.Context) (err error) {
+		attempts++
+		n, err = unlockScript.Run(ctx, z.client, []string{z.getLockKey()}, token).Int()
+		return
+	})
 	if err != nil {
-		return fmt.Errorf("redis hash remove fail, err:%w", err)
+		return fmt.Errorf("redis hash ring unlock fail, err: %w", err)
 	}
-
-	if len(entities) > 1 {
-		return fmt.Errorf("invalid score entity len: %d", len(entities))
+	// 重试时返回 0 可能是之前的尝试已经删除了锁，视为成功
+	if n == 0 && attempts == 1 {
+		return ErrLockNotHeld
 	}
 
-	members := z.UnmarshalEntries(entities)
+	return nil
+}
 
-	// 判断虚拟节点中是否存在对应的真实节点
-	index := -1
-	for i, member := range members {
-		if member == rawNodeKey {
-			index = i
-			break
+// renew 每隔过期时间的 1/3 续期一次，直到 stop 被关闭
+func (z *ZSetHashRing) renew(token string, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(defaultExpireSecond / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			var n int
+			err := z.do(context.Background(), func(ctx context.Context) (err error) {
+				n, err = renewScript.Run(ctx, z.client, []string{z.getLockKey()},
+					token, defaultExpireSecond.Milliseconds()).Int()
+				return
+			})
+			// 锁已经不属于自己，无需继续续期
+			if err == nil && n == 0 {
+				return
+			}
 		}
 	}
+}
 
-	// 虚拟节点中不存在真实节点
-	if index == -1 {
-		return nil
+// AddNode 添加节点
+// 相同 score 可能存在多个节点（发生hash冲突），如果冲突了需要合并放到一个元素中
+// 查询、合并、写回在同一个 lua 脚本中完成，保证原子性，因此失败后可以安全重试
+func (z *ZSetHashRing) AddNode(ctx context.Context, node string, virtualNode uint64, idx int) error {
+	score := strconv.FormatUint(virtualNode, 10)
+	rawNodeKey := z.getRawNodeKey(node, idx)
+
+	var n, attempts int
+	err := z.do(ctx, func(ctx context.Context) (err error) {
+		attempts++
+		n, err = addNodeScript.Run(ctx, z.client, []string{z.key, z.getVirtualNodesKey()}, score, rawNodeKey).Int()
+		return
+	})
+	if err != nil {
+		return fmt.Errorf("redis hash add fail, err:%w", err)
 	}
 
-	// 从 member 中删除该真实节点
-	members = append(members[:index], members[index+1:]...)
+	// 哈希环发生了变化，通知订阅方
+	// 重试时无法确定之前的尝试是否已生效，订阅方应用事件是幂等的，直接发布
+	if n == 1 || attempts > 1 {
+		z.publish(RingEvent{Type: NodeAdded, Node: node, VirtualNode: virtualNode, Idx: idx})
+	}
+
+	return nil
+}
+
+// RemoveNode 删除节点
+// 相同 score 可能存在多个节点，此时需要先找到要删除的节点，再进行删除其中一个
+// 查询、删除、写回在同一个 lua 脚本中完成，保证原子性，因此失败后可以安全重试
+func (z *ZSetHashRing) RemoveNode(ctx context.Context, node string, virtualNode uint64, idx int) error {
+	score := strconv.FormatUint(virtualNode, 10)
+	rawNodeKey := z.getRawNodeKey(node, idx)
 
-	// 删除整个元素
-	err = z.client.ZRemRangeByScore(context.Background(), z.key, score, score).Err()
+	var n, attempts int
+	err := z.do(ctx, func(ctx context.Context) (err error) {
+		attempts++
+		n, err = removeNodeScript.Run(ctx, z.client, []string{z.key, z.getVirtualNodesKey()}, score, rawNodeKey).Int()
+		return
+	})
 	if err != nil {
-		return fmt.Errorf("redis ring add fail, err: %w", err)
+		return fmt.Errorf("redis hash remove fail, err:%w", err)
 	}
 
-	// 如果已经空了，不必再添加
-	if len(members) == 0 {
-		return nil
+	// 哈希环发生了变化，通知订阅方
+	// 重试时无法确定之前的尝试是否已生效，订阅方应用事件是幂等的，直接发布
+	if n == 1 || attempts > 1 {
+		z.publish(RingEvent{Type: NodeRemoved, Node: node, VirtualNode: virtualNode, Idx: idx})
 	}
 
-	// 新建元素
-	entitiesBytes := z.MarshalEntries(members)
-	return z.client.ZAdd(context.Background(), z.key, &redis.Z{Score: float64(virtualNode), Member: entitiesBytes}).Err()
+	return nil
 }
 
 // ContainsNode 检查节点是否存在
-func (z *ZSetHashRing) ContainsNode(node string) bool {
+func (z *ZSetHashRing) ContainsNode(ctx context.Context, node string) (bool, error) {
 	// 使用第一个去尝试看是否存在
 	rawNodeKey := z.getRawNodeKey(node, 0)
-	entitiesBytes := z.MarshalEntries([]string{rawNodeKey})
-	err := z.client.ZScore(context.Background(), z.key, string(entitiesBytes)).Err()
-	return !errors.Is(err, redis.Nil)
+	err := z.do(ctx, func(ctx context.Context) error {
+		return containsNodeScript.Run(ctx, z.client, []string{z.key}, rawNodeKey).Err()
+	})
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("redis hash contains fail, err: %w", err)
+	}
+
+	return true, nil
 }
 
 // GetNode 根据hash获取节点
 // 1. 根据hash找到虚拟节点
 // 2. 返回虚拟节点对应的一个真实节点
-func (z *ZSetHashRing) GetNode(hash uint64) (string, bool) {
+func (z *ZSetHashRing) GetNode(ctx context.Context, hash uint64) (string, bool, error) {
 	// 找到虚拟节点
-	members, ok := z.getVirtualNode(hash)
-	if !ok {
-		return "", false
+	members, err := z.getVirtualNode(ctx, hash)
+	if err != nil {
+		return "", false, err
+	}
+	if len(members) == 0 {
+		return "", false, nil
 	}
 
 	if len(members) == 1 {
-		return z.getRawNode(members[0]), true
+		return z.getRawNode(members[0]), true, nil
 	}
 
 	// 如果存在多个，随机一个返回
-	return members[rand.Intn(len(members))], true
+	return z.getRawNode(members[rand.Intn(len(members))]), true, nil
+}
+
+// Walk 从 hash 开始顺时针遍历真实节点，fn 返回 false 时停止
+// 先分批遍历 [hash, +inf) 区间，再绕回遍历 (-inf, hash) 区间
+func (z *ZSetHashRing) Walk(ctx context.Context, hash uint64, fn func(virtualNode uint64, node string) bool) error {
+	score := strconv.FormatUint(hash, 10)
+	ranges := [][2]string{{score, "+inf"}, {"-inf", "(" + score}}
+
+	for _, r := range ranges {
+		for offset := int64(0); ; offset += walkBatchSize {
+			var entries []redis.Z
+			err := z.do(ctx, func(ctx context.Context) (err error) {
+				entries, err = z.client.ZRangeByScoreWithScores(ctx, z.key, &redis.ZRangeBy{
+					Min:    r[0],
+					Max:    r[1],
+					Offset: offset,
+					Count:  walkBatchSize,
+				}).Result()
+				return
+			})
+			if err != nil {
+				return fmt.Errorf("redis hash walk fail, err: %w", err)
+			}
+
+			// 先取出本批次全部的真实节点key，再批量查询虚拟节点的精确值
+			var rawNodeKeys []string
+			scores := make(map[string]float64)
+			for _, entry := range entries {
+				raw, _ := entry.Member.(string)
+				for _, rawNodeKey := range z.UnmarshalEntries([]string{raw}) {
+					rawNodeKeys = append(rawNodeKeys, rawNodeKey)
+					scores[rawNodeKey] = entry.Score
+				}
+			}
+			virtualNodes, err := z.getVirtualNodes(ctx, rawNodeKeys)
+			if err != nil {
+				return fmt.Errorf("redis hash walk fail, err: %w", err)
+			}
+
+			for _, rawNodeKey := range rawNodeKeys {
+				virtualNode, ok := virtualNodes[rawNodeKey]
+				if !ok {
+					// 查询期间被删除，使用 score 兜底
+					virtualNode = uint64(scores[rawNodeKey])
+				}
+				if !fn(virtualNode, z.getRawNode(rawNodeKey)) {
+					return nil
+				}
+			}
+
+			if len(entries) < walkBatchSize {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// getVirtualNodes 批量查询真实节点key对应的虚拟节点精确值，不存在的key不会出现在结果中
+func (z *ZSetHashRing) getVirtualNodes(ctx context.Context, rawNodeKeys []string) (map[string]uint64, error) {
+	if len(rawNodeKeys) == 0 {
+		return nil, nil
+	}
+
+	var values []interface{}
+	err := z.do(ctx, func(ctx context.Context) (err error) {
+		values, err = z.client.HMGet(ctx, z.getVirtualNodesKey(), rawNodeKeys...).Result()
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	virtualNodes := make(map[string]uint64, len(values))
+	for i, value := range values {
+		str, ok := value.(string)
+		if !ok {
+			continue
+		}
+		if virtualNode, err := strconv.ParseUint(str, 10, 64); err == nil {
+			virtualNodes[rawNodeKeys[i]] = virtualNode
+		}
+	}
+
+	return virtualNodes, nil
 }
 
 // 获取虚拟节点对应的真实节点列表
 // 先尝试 [hash, +inf) 区间内的第一个节点（顺时针），如果没找到，再找 (-inf, hash] 区间内的第一个节点（绕一个环回去找）
-func (z *ZSetHashRing) getVirtualNode(hash uint64) ([]string, bool) {
+func (z *ZSetHashRing) getVirtualNode(ctx context.Context, hash uint64) ([]string, error) {
 	zrangeBy := &redis.ZRangeBy{
 		Min:    strconv.FormatUint(hash, 10),
 		Max:    "+inf",
@@ -181,25 +495,68 @@ func (z *ZSetHashRing) getVirtualNode(hash uint64) ([]string, bool) {
 	}
 
 	// 首先找 [hash, +inf] 区间内的第一个节点
-	entries, err := z.client.ZRangeByScore(context.Background(), z.key, zrangeBy).Result()
+	var entries []string
+	err := z.do(ctx, func(ctx context.Context) (err error) {
+		entries, err = z.client.ZRangeByScore(ctx, z.key, zrangeBy).Result()
+		return
+	})
 	if err != nil && !errors.Is(err, redis.Nil) {
-		return nil, false
+		return nil, fmt.Errorf("redis hash get fail, err: %w", err)
 	}
 
 	if len(entries) != 0 {
-		return z.UnmarshalEntries(entries), true
+		return z.UnmarshalEntries(entries), nil
 	}
 
 	// 如果没找到，反过来找 [-inf, hash] 区间的第一个节点
 	zrangeBy.Max = zrangeBy.Min
 	zrangeBy.Min = "-inf"
 
-	entries, err = z.client.ZRangeByScore(context.Background(), z.key, zrangeBy).Result()
-	if err != nil && !errors.Is(err, redis.Nil) || len(entries) == 0 {
-		return nil, false
+	err = z.do(ctx, func(ctx context.Context) (err error) {
+		entries, err = z.client.ZRangeByScore(ctx, z.key, zrangeBy).Result()
+		return
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("redis hash get fail, err: %w", err)
+	}
+
+	return z.UnmarshalEntries(entries), nil
+}
+
+// do 执行一次 redis 操作，每次尝试单独设置超时，网络错误、超时等失败时按退避间隔重试
+// redis 返回的错误（如 lua 执行错误）、redis.Nil 以及 ctx 结束时不重试
+func (z *ZSetHashRing) do(ctx context.Context, fn func(ctx context.Context) error) error {
+	var err error
+	for i := 0; i <= z.retries; i++ {
+		if i > 0 {
+			select {
+			case <-time.After(retryBackoff * time.Duration(i)):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		err = func() error {
+			opCtx, cancel := context.WithTimeout(ctx, z.timeout)
+			defer cancel()
+			return fn(opCtx)
+		}()
+		if err == nil || ctx.Err() != nil || !retryable(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// 判断错误是否可以重试，redis 服务端返回的错误不重试
+func retryable(err error) bool {
+	if errors.Is(err, redis.Nil) {
+		return false
 	}
 
-	return z.UnmarshalEntries(entries), true
+	var redisErr redis.Error
+	return !errors.As(err, &redisErr)
 }
 
 func (z *ZSetHashRing) MarshalEntries(members []string) []byte {
@@ -0,0 +1,165 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+var bg = context.Background()
+
+func newTestRing(t *testing.T) (*ZSetHashRing, *miniredis.Miniredis) {
+	m := miniredis.RunT(t)
+	return NewZSetHashRing("hashRing", m.Addr(), ""), m
+}
+
+func contains(t *testing.T, ring interface {
+	ContainsNode(ctx context.Context, node string) (bool, error)
+}, node string) bool {
+	ok, err := ring.ContainsNode(bg, node)
+	assert.Nil(t, err)
+	return ok
+}
+
+func TestZSetHashRing_Lock(t *testing.T) {
+	ring, m := newTestRing(t)
+	// 模拟另一个进程
+	other := NewZSetHashRing("hashRing", m.Addr(), "")
+
+	assert.Nil(t, ring.Lock(bg))
+
+	acquired := make(chan struct{})
+	go func() {
+		assert.Nil(t, other.Lock(bg))
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("lock acquired while held by another ring")
+	case <-time.After(lockRetryInterval * 3):
+	}
+
+	assert.Nil(t, ring.Unlock())
+	<-acquired
+	assert.Nil(t, other.Unlock())
+}
+
+func TestZSetHashRing_LockIdempotent(t *testing.T) {
+	ring, m := newTestRing(t)
+	keys := []string{ring.getLockKey()}
+
+	// 上一次尝试已在服务端成功，重试时仍然返回成功
+	for i := 0; i < 2; i++ {
+		n, err := lockScript.Run(bg, ring.client, keys, "token", 5000).Int()
+		assert.Nil(t, err)
+		assert.Equal(t, 1, n)
+	}
+	assert.True(t, m.TTL(ring.getLockKey()) > 0)
+
+	// 他人持有时加锁失败
+	n, err := lockScript.Run(bg, ring.client, keys, "other", 5000).Int()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, n)
+	val, _ := m.Get(ring.getLockKey())
+	assert.Equal(t, "token", val)
+}
+
+func TestZSetHashRing_UnlockNotHeld(t *testing.T) {
+	ring, m := newTestRing(t)
+
+	assert.Nil(t, ring.Lock(bg))
+	// 锁过期后被其他人持有
+	m.Del(ring.getLockKey())
+	assert.Nil(t, m.Set(ring.getLockKey(), "other"))
+
+	assert.ErrorIs(t, ring.Unlock(), ErrLockNotHeld)
+	// 不能误删他人的锁
+	val, err := m.Get(ring.getLockKey())
+	assert.Nil(t, err)
+	assert.Equal(t, "other", val)
+}
+
+func TestZSetHashRing_UnlockWithoutLock(t *testing.T) {
+	m := miniredis.RunT(t)
+	ring := NewZSetHashRing("hashRing", m.Addr(), "", WithRetries(0))
+
+	// redis 不可用时加锁失败，此时解锁不能 panic，也不能阻塞
+	m.Close()
+	assert.NotNil(t, ring.Lock(bg))
+	assert.ErrorIs(t, ring.Unlock(), ErrLockNotHeld)
+
+	// 未持有锁时解锁不影响之后的加锁
+	assert.Nil(t, m.Restart())
+	assert.ErrorIs(t, ring.Unlock(), ErrLockNotHeld)
+	assert.Nil(t, ring.Lock(bg))
+	assert.Nil(t, ring.Unlock())
+}
+
+func TestZSetHashRing_AddRemoveNode(t *testing.T) {
+	ring, _ := newTestRing(t)
+
+	_, ok, _ := ring.GetNode(bg, 1)
+	assert.False(t, ok)
+
+	assert.Nil(t, ring.AddNode(bg, "first", 100, 0))
+	assert.True(t, contains(t, ring, "first"))
+	// 同一个 score 上发生冲突
+	assert.Nil(t, ring.AddNode(bg, "second", 100, 0))
+	// 重复添加
+	assert.Nil(t, ring.AddNode(bg, "first", 100, 0))
+
+	for i := 0; i < 10; i++ {
+		node, ok, _ := ring.GetNode(bg, 50)
+		assert.True(t, ok)
+		assert.Contains(t, []string{"first", "second"}, node)
+	}
+
+	assert.Nil(t, ring.RemoveNode(bg, "first", 100, 0))
+	// 重复删除
+	assert.Nil(t, ring.RemoveNode(bg, "first", 100, 0))
+	node, ok, _ := ring.GetNode(bg, 200)
+	assert.True(t, ok)
+	assert.Equal(t, "second", node)
+
+	assert.Nil(t, ring.RemoveNode(bg, "second", 100, 0))
+	_, ok, _ = ring.GetNode(bg, 50)
+	assert.False(t, ok)
+	assert.False(t, contains(t, ring, "second"))
+}
+
+func TestZSetHashRing_LockCtx(t *testing.T) {
+	ring, m := newTestRing(t)
+	other := NewZSetHashRing("hashRing", m.Addr(), "")
+
+	assert.Nil(t, ring.Lock(bg))
+	defer ring.Unlock()
+
+	ctx, cancel := context.WithTimeout(bg, lockRetryInterval*2)
+	defer cancel()
+	assert.ErrorIs(t, other.Lock(ctx), context.DeadlineExceeded)
+}
+
+func TestZSetHashRing_Retry(t *testing.T) {
+	m := miniredis.RunT(t)
+	ring := NewZSetHashRing("hashRing", m.Addr(), "", WithRetries(3), WithTimeout(100*time.Millisecond))
+
+	// redis 短暂不可用，重试后成功
+	m.Close()
+	go func() {
+		time.Sleep(retryBackoff)
+		_ = m.Restart()
+	}()
+	assert.Nil(t, ring.AddNode(bg, "first", 100, 0))
+	assert.True(t, contains(t, ring, "first"))
+
+	// 不重试时直接返回错误
+	ring = NewZSetHashRing("hashRing", m.Addr(), "", WithRetries(0))
+	m.Close()
+	assert.NotNil(t, ring.AddNode(bg, "second", 200, 0))
+	_, err := ring.ContainsNode(bg, "second")
+	assert.NotNil(t, err)
+}
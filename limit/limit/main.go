@@ -0,0 +1,31 @@
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+	limit "go-zero-source/limit/limit/source"
+	"net/http"
+)
+
+// 每秒 10 个令牌，最多突发 20 个请求
+var limiter limit.Limiter = limit.NewTokenBucket(10, 20)
+
+// RateLimitWrapper 限流中间件，超过限制时直接返回 429
+func RateLimitWrapper(l limit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !l.Allow() {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"msg": "too many requests"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func main() {
+	r := gin.Default()
+	r.GET("/ping", RateLimitWrapper(limiter), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"msg": "ok"})
+	})
+
+	r.Run()
+}
@@ -0,0 +1,53 @@
+package limit
+
+import (
+	"context"
+	"time"
+
+	"go-zero-source/util/clock"
+)
+
+// Limiter 限流器
+type Limiter interface {
+	// Allow 是否放行一个请求
+	Allow() bool
+	// AllowN 是否放行 n 个请求，要么全部放行，要么全部拒绝
+	AllowN(n int) bool
+	// Wait 阻塞直到放行一个请求，ctx 结束时返回 ctx.Err()
+	Wait(ctx context.Context) error
+}
+
+type Option func(opt *options)
+
+type options struct {
+	clock clock.Clock
+}
+
+// WithClock 指定时钟，测试时可以传入 clocktest.FakeClock
+func WithClock(c clock.Clock) Option {
+	return func(opt *options) {
+		opt.clock = c
+	}
+}
+
+func newOptions(opts ...Option) options {
+	o := options{clock: clock.Default}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// sleep 等待 d，ctx 结束时提前返回 ctx.Err()
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,92 @@
+package limit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"go-zero-source/util/clock/clocktest"
+)
+
+func TestSlidingLog(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	var l Limiter = NewSlidingLog(3, time.Second, WithClock(clk))
+
+	assert.True(t, l.Allow())
+	clk.Advance(500 * time.Millisecond)
+	assert.True(t, l.AllowN(2))
+	assert.False(t, l.Allow())
+
+	// 第一个请求滑出窗口
+	clk.Advance(500 * time.Millisecond)
+	assert.True(t, l.Allow())
+	assert.False(t, l.Allow())
+	assert.False(t, l.AllowN(4))
+}
+
+func TestSlidingWindow(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	var l Limiter = NewSlidingWindow(3, 2, 500*time.Millisecond, WithClock(clk))
+
+	assert.True(t, l.AllowN(2))
+	clk.Advance(500 * time.Millisecond)
+	assert.True(t, l.Allow())
+	assert.False(t, l.Allow())
+
+	// 第一个桶过期
+	clk.Advance(500 * time.Millisecond)
+	assert.True(t, l.AllowN(2))
+	assert.False(t, l.Allow())
+}
+
+func TestTokenBucket(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	var l Limiter = NewTokenBucket(10, 5, WithClock(clk))
+
+	assert.True(t, l.AllowN(5))
+	assert.False(t, l.Allow())
+
+	// 100ms 生成一个令牌
+	clk.Advance(100 * time.Millisecond)
+	assert.True(t, l.Allow())
+	assert.False(t, l.Allow())
+
+	// 令牌数不超过桶的容量
+	clk.Advance(time.Minute)
+	assert.True(t, l.AllowN(5))
+	assert.False(t, l.Allow())
+
+	// rate 非法时回退为每秒生成 burst 个令牌，Wait 不会因为等待时间溢出而直接放行
+	for _, rate := range []float64{0, -1} {
+		l := NewTokenBucket(rate, 5, WithClock(clk))
+		assert.Equal(t, float64(5), l.rate)
+		assert.True(t, l.AllowN(5))
+		assert.False(t, l.Allow())
+		clk.Advance(200 * time.Millisecond)
+		assert.True(t, l.Allow())
+	}
+	assert.Equal(t, float64(1), NewTokenBucket(0, 0).rate)
+}
+
+func TestWait(t *testing.T) {
+	limiters := map[string]Limiter{
+		"SlidingLog":    NewSlidingLog(1, 50*time.Millisecond),
+		"SlidingWindow": NewSlidingWindow(1, 5, 10*time.Millisecond),
+		"TokenBucket":   NewTokenBucket(20, 1),
+	}
+	for name, l := range limiters {
+		t.Run(name, func(t *testing.T) {
+			assert.Nil(t, l.Wait(context.Background()))
+
+			start := time.Now()
+			assert.Nil(t, l.Wait(context.Background()))
+			assert.True(t, time.Since(start) >= 40*time.Millisecond)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			assert.Equal(t, context.DeadlineExceeded, l.Wait(ctx))
+		})
+	}
+}
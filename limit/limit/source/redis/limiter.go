@@ -0,0 +1,65 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"go-zero-source/util/clock"
+)
+
+// 基于 redis 的限流器，多个实例共享同一个 key 即共享同一份配额
+// 判断与扣减都在 lua 脚本中完成，保证原子性；时间取自调用方的时钟，要求各实例的时钟基本同步
+// Allow、AllowN 无法返回错误，redis 出错时放行，需要感知错误时使用 AllowNCtx
+
+type Option func(opt *options)
+
+type options struct {
+	clock clock.Clock
+}
+
+// WithClock 指定时钟，测试时可以传入 clocktest.FakeClock
+func WithClock(c clock.Clock) Option {
+	return func(opt *options) {
+		opt.clock = c
+	}
+}
+
+func newOptions(opts ...Option) options {
+	o := options{clock: clock.Default}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// wait 反复尝试放行一个请求，被拒绝时按 take 返回的时间等待，redis 出错时返回错误
+func wait(ctx context.Context, take func(ctx context.Context) (bool, time.Duration, error)) error {
+	for {
+		ok, d, err := take(ctx)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+
+		timer := time.NewTimer(d)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// parseResult 解析脚本返回的 {是否放行, 需要等待的毫秒数}
+func parseResult(val interface{}, err error) (bool, time.Duration, error) {
+	if err != nil {
+		return false, 0, err
+	}
+
+	res := val.([]interface{})
+	return res[0].(int64) == 1, time.Duration(res[1].(int64)) * time.Millisecond, nil
+}
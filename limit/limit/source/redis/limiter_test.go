@@ -0,0 +1,123 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+
+	limit "go-zero-source/limit/limit/source"
+	"go-zero-source/util/clock/clocktest"
+)
+
+func newTestClient(t *testing.T) (*redis.Client, *miniredis.Miniredis) {
+	m := miniredis.RunT(t)
+	return redis.NewClient(&redis.Options{Addr: m.Addr()}), m
+}
+
+func TestSlidingLog(t *testing.T) {
+	client, _ := newTestClient(t)
+	clk := clocktest.NewFakeClock(time.Now())
+	var l limit.Limiter = NewSlidingLog(client, "log", 3, time.Second, WithClock(clk))
+	// 另一个实例共享配额
+	other := NewSlidingLog(client, "log", 3, time.Second, WithClock(clk))
+
+	assert.True(t, l.Allow())
+	clk.Advance(500 * time.Millisecond)
+	assert.True(t, other.AllowN(2))
+	assert.False(t, l.Allow())
+
+	_, wait, err := other.take(context.Background(), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	// 第一个请求滑出窗口
+	clk.Advance(500 * time.Millisecond)
+	assert.True(t, l.Allow())
+	ok, err := other.AllowNCtx(context.Background(), 1)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestSlidingWindow(t *testing.T) {
+	client, _ := newTestClient(t)
+	clk := clocktest.NewFakeClock(time.Unix(0, 0))
+	var l limit.Limiter = NewSlidingWindow(client, "window", 3, 2, 500*time.Millisecond, WithClock(clk))
+
+	assert.True(t, l.AllowN(2))
+	clk.Advance(500 * time.Millisecond)
+	assert.True(t, l.Allow())
+	assert.False(t, l.Allow())
+
+	// 第一个桶过期
+	clk.Advance(500 * time.Millisecond)
+	assert.True(t, l.AllowN(2))
+	assert.False(t, l.Allow())
+}
+
+func TestTokenBucket(t *testing.T) {
+	client, m := newTestClient(t)
+	clk := clocktest.NewFakeClock(time.Now())
+	tb := NewTokenBucket(client, "bucket", 10, 5, WithClock(clk))
+	var l limit.Limiter = tb
+
+	assert.True(t, l.AllowN(5))
+	_, wait, err := tb.take(context.Background(), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 100*time.Millisecond, wait)
+
+	// 100ms 生成一个令牌
+	clk.Advance(100 * time.Millisecond)
+	assert.True(t, l.Allow())
+	assert.False(t, l.Allow())
+
+	// 令牌数不超过桶的容量
+	clk.Advance(time.Minute)
+	assert.True(t, l.AllowN(5))
+	assert.False(t, l.Allow())
+	assert.Equal(t, time.Second, m.TTL("bucket"))
+
+	// rate 非法时回退为每秒生成 burst 个令牌，避免脚本中除以 0
+	l = NewTokenBucket(client, "invalid", 0, 5, WithClock(clk))
+	assert.True(t, l.AllowN(5))
+	_, wait, err = l.(*TokenBucket).take(context.Background(), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 200*time.Millisecond, wait)
+}
+
+func TestWait(t *testing.T) {
+	client, _ := newTestClient(t)
+	limiters := map[string]limit.Limiter{
+		"SlidingLog":    NewSlidingLog(client, "log", 1, 50*time.Millisecond),
+		"SlidingWindow": NewSlidingWindow(client, "window", 1, 5, 10*time.Millisecond),
+		"TokenBucket":   NewTokenBucket(client, "bucket", 20, 1),
+	}
+	for name, l := range limiters {
+		t.Run(name, func(t *testing.T) {
+			assert.Nil(t, l.Wait(context.Background()))
+
+			start := time.Now()
+			assert.Nil(t, l.Wait(context.Background()))
+			assert.True(t, time.Since(start) >= 40*time.Millisecond)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			assert.Equal(t, context.DeadlineExceeded, l.Wait(ctx))
+		})
+	}
+}
+
+func TestRedisDown(t *testing.T) {
+	client, m := newTestClient(t)
+	l := NewTokenBucket(client, "bucket", 1, 1)
+	m.Close()
+
+	// redis 不可用时放行
+	assert.True(t, l.Allow())
+	_, err := l.AllowNCtx(context.Background(), 1)
+	assert.NotNil(t, err)
+	assert.NotNil(t, l.Wait(context.Background()))
+}
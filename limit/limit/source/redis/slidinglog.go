@@ -0,0 +1,81 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/zeromicro/go-zero/core/stringx"
+
+	"go-zero-source/util/clock"
+)
+
+// 滑动日志，使用 zset 记录窗口内每个放行请求的时间
+// KEYS[1]: 限流 key，ARGV[1]: 当前时间(ms)，ARGV[2]: 窗口长度(ms)，ARGV[3]: limit，ARGV[4]: n，ARGV[5]: 成员前缀
+var slidingLogScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+local n = tonumber(ARGV[4])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+local count = redis.call("ZCARD", KEYS[1])
+if count + n > limit then
+	local idx = count + n - limit - 1
+	if idx >= count then
+		return {0, window}
+	end
+	local entry = redis.call("ZRANGE", KEYS[1], idx, idx, "WITHSCORES")
+	return {0, tonumber(entry[2]) + window - now}
+end
+for i = 1, n do
+	redis.call("ZADD", KEYS[1], now, ARGV[5] .. ":" .. i)
+end
+redis.call("PEXPIRE", KEYS[1], window)
+return {1, 0}
+`)
+
+// SlidingLog 基于 redis zset 的滑动日志限流器
+type SlidingLog struct {
+	client *redis.Client
+	key    string
+	limit  int
+	window time.Duration
+	clock  clock.Clock
+}
+
+func NewSlidingLog(client *redis.Client, key string, limit int, window time.Duration, opts ...Option) *SlidingLog {
+	o := newOptions(opts...)
+	return &SlidingLog{
+		client: client,
+		key:    key,
+		limit:  limit,
+		window: window,
+		clock:  o.clock,
+	}
+}
+
+func (l *SlidingLog) Allow() bool {
+	return l.AllowN(1)
+}
+
+func (l *SlidingLog) AllowN(n int) bool {
+	ok, err := l.AllowNCtx(context.Background(), n)
+	return ok || err != nil
+}
+
+func (l *SlidingLog) AllowNCtx(ctx context.Context, n int) (bool, error) {
+	ok, _, err := l.take(ctx, n)
+	return ok, err
+}
+
+func (l *SlidingLog) Wait(ctx context.Context) error {
+	return wait(ctx, func(ctx context.Context) (bool, time.Duration, error) {
+		return l.take(ctx, 1)
+	})
+}
+
+func (l *SlidingLog) take(ctx context.Context, n int) (bool, time.Duration, error) {
+	// 成员需要唯一，同一毫秒内的多个请求才能分别记录
+	return parseResult(slidingLogScript.Run(ctx, l.client, []string{l.key},
+		l.clock.Now().UnixMilli(), l.window.Milliseconds(), l.limit, n, stringx.Randn(16)).Result())
+}
@@ -0,0 +1,86 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"go-zero-source/util/clock"
+)
+
+// 滑动窗口计数，hash 的 field 为桶的刻度，value 为桶内请求数，与 rollingwindow/redis.Window 的存储结构一致
+// KEYS[1]: 限流 key，ARGV[1]: 当前刻度，ARGV[2]: 桶的数量，ARGV[3]: limit，ARGV[4]: n，ARGV[5]: 过期时间(ms)
+var slidingWindowScript = redis.NewScript(`
+local current = tonumber(ARGV[1])
+local oldest = current - tonumber(ARGV[2])
+local kvs = redis.call("HGETALL", KEYS[1])
+local sum = 0
+for i = 1, #kvs, 2 do
+	local tick = tonumber(kvs[i])
+	if tick <= oldest then
+		redis.call("HDEL", KEYS[1], kvs[i])
+	elseif tick <= current then
+		sum = sum + tonumber(kvs[i + 1])
+	end
+end
+if sum + tonumber(ARGV[4]) > tonumber(ARGV[3]) then
+	return {0, 0}
+end
+redis.call("HINCRBY", KEYS[1], ARGV[1], ARGV[4])
+redis.call("PEXPIRE", KEYS[1], ARGV[5])
+return {1, 0}
+`)
+
+// SlidingWindow 基于 redis hash 的滑动窗口计数限流器
+type SlidingWindow struct {
+	client   *redis.Client
+	key      string
+	limit    int64
+	size     int
+	interval time.Duration
+	clock    clock.Clock
+}
+
+func NewSlidingWindow(client *redis.Client, key string, limit int64, size int, interval time.Duration,
+	opts ...Option) *SlidingWindow {
+	o := newOptions(opts...)
+	return &SlidingWindow{
+		client:   client,
+		key:      key,
+		limit:    limit,
+		size:     size,
+		interval: interval,
+		clock:    o.clock,
+	}
+}
+
+func (l *SlidingWindow) Allow() bool {
+	return l.AllowN(1)
+}
+
+func (l *SlidingWindow) AllowN(n int) bool {
+	ok, err := l.AllowNCtx(context.Background(), n)
+	return ok || err != nil
+}
+
+func (l *SlidingWindow) AllowNCtx(ctx context.Context, n int) (bool, error) {
+	ok, _, err := l.take(ctx, n)
+	return ok, err
+}
+
+// Wait 被拒绝时等待一个桶的时间后重试
+func (l *SlidingWindow) Wait(ctx context.Context) error {
+	return wait(ctx, func(ctx context.Context) (bool, time.Duration, error) {
+		ok, _, err := l.take(ctx, 1)
+		return ok, l.interval, err
+	})
+}
+
+func (l *SlidingWindow) take(ctx context.Context, n int) (bool, time.Duration, error) {
+	tick := l.clock.Now().UnixNano() / int64(l.interval)
+	// 多留一个刻度，避免窗口边界上的桶提前过期
+	ttl := time.Duration(l.size+1) * l.interval
+	return parseResult(slidingWindowScript.Run(ctx, l.client, []string{l.key},
+		tick, l.size, l.limit, n, ttl.Milliseconds()).Result())
+}
@@ -0,0 +1,104 @@
+package redis
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"go-zero-source/util/clock"
+)
+
+// 令牌桶，hash 中记录当前令牌数与上次计算的时间
+// KEYS[1]: 限流 key，ARGV[1]: 每秒生成的令牌数，ARGV[2]: 桶的容量，ARGV[3]: 当前时间(ms)，ARGV[4]: n，ARGV[5]: 过期时间(ms)
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local n = tonumber(ARGV[4])
+local data = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(data[1])
+local ts = tonumber(data[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+if now > ts then
+	tokens = math.min(burst, tokens + (now - ts) * rate / 1000)
+	ts = now
+end
+local allowed = 0
+local wait = 0
+if tokens >= n then
+	tokens = tokens - n
+	allowed = 1
+else
+	wait = math.ceil((n - tokens) * 1000 / rate)
+end
+redis.call("HSET", KEYS[1], "tokens", tokens, "ts", ts)
+redis.call("PEXPIRE", KEYS[1], ARGV[5])
+return {allowed, wait}
+`)
+
+// TokenBucket 基于 redis 的令牌桶限流器
+type TokenBucket struct {
+	client *redis.Client
+	key    string
+	rate   float64 // 每秒生成的令牌数
+	burst  int     // 桶的容量
+	clock  clock.Clock
+}
+
+// NewTokenBucket 创建令牌桶，rate 不大于 0 时令牌永远无法生成，回退为每秒生成 burst 个令牌
+func NewTokenBucket(client *redis.Client, key string, rate float64, burst int, opts ...Option) *TokenBucket {
+	o := newOptions(opts...)
+	return &TokenBucket{
+		client: client,
+		key:    key,
+		rate:   tokenRate(rate, burst),
+		burst:  burst,
+		clock:  o.clock,
+	}
+}
+
+func (l *TokenBucket) Allow() bool {
+	return l.AllowN(1)
+}
+
+func (l *TokenBucket) AllowN(n int) bool {
+	ok, err := l.AllowNCtx(context.Background(), n)
+	return ok || err != nil
+}
+
+func (l *TokenBucket) AllowNCtx(ctx context.Context, n int) (bool, error) {
+	ok, _, err := l.take(ctx, n)
+	return ok, err
+}
+
+// Wait 被拒绝时按令牌生成所需的时间等待后重试
+// 与本地令牌桶不同，这里不预占令牌，多个实例同时等待时不保证先到先得
+func (l *TokenBucket) Wait(ctx context.Context) error {
+	return wait(ctx, func(ctx context.Context) (bool, time.Duration, error) {
+		return l.take(ctx, 1)
+	})
+}
+
+func (l *TokenBucket) take(ctx context.Context, n int) (bool, time.Duration, error) {
+	// 桶从空到满所需时间的两倍，key 过期后等价于满桶
+	ttl := int64(math.Ceil(float64(l.burst)/l.rate*1000)) * 2
+	return parseResult(tokenBucketScript.Run(ctx, l.client, []string{l.key},
+		l.rate, l.burst, l.clock.Now().UnixMilli(), n, ttl).Result())
+}
+
+// tokenRate rate 非法时回退为每秒生成 burst 个令牌（至少 1 个），即 1s 填满桶
+func tokenRate(rate float64, burst int) float64 {
+	if rate > 0 {
+		return rate
+	}
+	if burst > 0 {
+		return float64(burst)
+	}
+
+	return 1
+}
@@ -0,0 +1,85 @@
+package limit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-zero-source/util/clock"
+)
+
+// SlidingLog 滑动日志限流器
+// 记录窗口内每个放行请求的时间，任意长度为 window 的时间段内放行的请求数都不超过 limit
+// 精确但内存占用与 limit 成正比，适用于 limit 较小的场景
+type SlidingLog struct {
+	lock   sync.Mutex
+	limit  int
+	window time.Duration
+	clock  clock.Clock
+	log    []time.Time // 窗口内放行请求的时间，按时间先后排列
+}
+
+func NewSlidingLog(limit int, window time.Duration, opts ...Option) *SlidingLog {
+	o := newOptions(opts...)
+	return &SlidingLog{
+		limit:  limit,
+		window: window,
+		clock:  o.clock,
+	}
+}
+
+func (l *SlidingLog) Allow() bool {
+	return l.AllowN(1)
+}
+
+func (l *SlidingLog) AllowN(n int) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	ok, _ := l.take(n)
+	return ok
+}
+
+func (l *SlidingLog) Wait(ctx context.Context) error {
+	for {
+		l.lock.Lock()
+		ok, wait := l.take(1)
+		l.lock.Unlock()
+		if ok {
+			return nil
+		}
+
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// take 尝试放行 n 个请求，拒绝时返回需要等待的时间
+func (l *SlidingLog) take(n int) (bool, time.Duration) {
+	now := l.clock.Now()
+
+	// 清理窗口外的记录
+	boundary := now.Add(-l.window)
+	expired := 0
+	for expired < len(l.log) && !l.log[expired].After(boundary) {
+		expired++
+	}
+	l.log = l.log[expired:]
+
+	if len(l.log)+n > l.limit {
+		// 需要等到足够多的记录滑出窗口
+		idx := len(l.log) + n - l.limit - 1
+		if idx >= len(l.log) {
+			// n 超过 limit，永远无法放行，按整个窗口等待
+			return false, l.window
+		}
+		return false, l.log[idx].Add(l.window).Sub(now)
+	}
+
+	for i := 0; i < n; i++ {
+		l.log = append(l.log, now)
+	}
+
+	return true, 0
+}
@@ -0,0 +1,57 @@
+package limit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-zero-source/rollingwindow/rollingwindow"
+)
+
+// SlidingWindow 滑动窗口计数限流器，基于 rollingwindow.Window
+// 窗口由 size 个长度为 interval 的桶组成，窗口内的请求数不超过 limit
+// 内存占用固定，精度取决于桶的粒度
+type SlidingWindow struct {
+	lock     sync.Mutex
+	limit    int64
+	interval time.Duration
+	window   *rollingwindow.Window
+}
+
+func NewSlidingWindow(limit int64, size int, interval time.Duration, opts ...Option) *SlidingWindow {
+	o := newOptions(opts...)
+	return &SlidingWindow{
+		limit:    limit,
+		interval: interval,
+		window: rollingwindow.NewWindow(rollingwindow.WithSize(size), rollingwindow.WithInterval(interval),
+			rollingwindow.WithClock(o.clock)),
+	}
+}
+
+func (l *SlidingWindow) Allow() bool {
+	return l.AllowN(1)
+}
+
+func (l *SlidingWindow) AllowN(n int) bool {
+	// 统计与累加需要在同一把锁内完成，避免并发请求同时通过检查
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.window.Reduce()+int64(n) > l.limit {
+		return false
+	}
+
+	l.window.Add(int64(n))
+	return true
+}
+
+// Wait 被拒绝时等待一个桶的时间后重试，最早的桶过期后才可能放行
+func (l *SlidingWindow) Wait(ctx context.Context) error {
+	for !l.Allow() {
+		if err := sleep(ctx, l.interval); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
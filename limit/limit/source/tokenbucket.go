@@ -0,0 +1,106 @@
+package limit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-zero-source/util/clock"
+)
+
+// TokenBucket 令牌桶限流器
+// 以 rate 个/秒的速度向桶中放入令牌，桶中最多存放 burst 个令牌，每个请求消耗一个令牌
+// 允许突发流量，长期平均速率不超过 rate
+type TokenBucket struct {
+	lock     sync.Mutex
+	rate     float64 // 每秒生成的令牌数
+	burst    int     // 桶的容量
+	clock    clock.Clock
+	tokens   float64   // 当前令牌数，Wait 预占令牌时可能为负数
+	lastTime time.Time // 上次计算令牌数的时间
+}
+
+// NewTokenBucket 创建令牌桶，rate 不大于 0 时令牌永远无法生成，回退为每秒生成 burst 个令牌
+func NewTokenBucket(rate float64, burst int, opts ...Option) *TokenBucket {
+	o := newOptions(opts...)
+	return &TokenBucket{
+		rate:     tokenRate(rate, burst),
+		burst:    burst,
+		clock:    o.clock,
+		tokens:   float64(burst),
+		lastTime: o.clock.Now(),
+	}
+}
+
+func (l *TokenBucket) Allow() bool {
+	return l.AllowN(1)
+}
+
+func (l *TokenBucket) AllowN(n int) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.refill()
+	if l.tokens < float64(n) {
+		return false
+	}
+
+	l.tokens -= float64(n)
+	return true
+}
+
+// Wait 预占一个令牌，等到令牌生成后返回，ctx 提前结束时归还令牌
+func (l *TokenBucket) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	l.lock.Lock()
+	l.refill()
+	l.tokens--
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.lock.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	if err := sleep(ctx, wait); err != nil {
+		l.lock.Lock()
+		l.tokens++
+		l.lock.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// refill 按经过的时间补充令牌
+func (l *TokenBucket) refill() {
+	now := l.clock.Now()
+	elapsed := now.Sub(l.lastTime)
+	l.lastTime = now
+	if elapsed <= 0 {
+		return
+	}
+
+	l.tokens += elapsed.Seconds() * l.rate
+	if l.tokens > float64(l.burst) {
+		l.tokens = float64(l.burst)
+	}
+}
+
+// tokenRate rate 非法时回退为每秒生成 burst 个令牌（至少 1 个），即 1s 填满桶
+func tokenRate(rate float64, burst int) float64 {
+	if rate > 0 {
+		return rate
+	}
+	if burst > 0 {
+		return float64(burst)
+	}
+
+	return 1
+}
@@ -0,0 +1,93 @@
+package load
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// cpu 使用率的采样间隔
+	cpuRefreshInterval = 250 * time.Millisecond
+	// 使用率的平滑系数，旧值的权重
+	cpuBeta = 0.95
+)
+
+var (
+	errInvalidCpuStat = errors.New("invalid /proc/stat content")
+
+	cpuOnce  sync.Once
+	cpuUsage int64 // 平滑后的 cpu 使用率，取值 [0, 1000]
+)
+
+// CpuUsage 当前的 cpu 使用率，取值 [0, 1000]，1000 表示 100%
+// 首次创建 Shedder 时开始后台采样，读取 /proc/stat 失败（例如非 linux 系统）时恒为 0
+func CpuUsage() int64 {
+	return atomic.LoadInt64(&cpuUsage)
+}
+
+// startCpuSampler 启动后台采样协程，只启动一次
+func startCpuSampler() {
+	cpuOnce.Do(func() {
+		prevBusy, prevTotal, err := readCpuStat()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			ticker := time.NewTicker(cpuRefreshInterval)
+			defer ticker.Stop()
+
+			for range ticker.C {
+				busy, total, err := readCpuStat()
+				if err != nil || total <= prevTotal {
+					continue
+				}
+
+				usage := float64(busy-prevBusy) * 1000 / float64(total-prevTotal)
+				prevBusy, prevTotal = busy, total
+				smoothed := float64(atomic.LoadInt64(&cpuUsage))*cpuBeta + usage*(1-cpuBeta)
+				atomic.StoreInt64(&cpuUsage, int64(smoothed))
+			}
+		}()
+	})
+}
+
+func readCpuStat() (busy, total uint64, err error) {
+	data, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return parseCpuStat(string(data))
+}
+
+// parseCpuStat 解析 /proc/stat 第一行的总 cpu 时间
+// cpu user nice system idle iowait irq softirq steal guest guest_nice
+// guest 已经计入 user，只取前 8 列，idle 与 iowait 视为空闲
+func parseCpuStat(content string) (busy, total uint64, err error) {
+	line, _, _ := strings.Cut(content, "\n")
+	fields := strings.Fields(line)
+	if len(fields) < 9 || fields[0] != "cpu" {
+		return 0, 0, errInvalidCpuStat
+	}
+
+	var idle uint64
+	for i, field := range fields[1:9] {
+		v, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		total += v
+		if i == 3 || i == 4 {
+			idle += v
+		}
+	}
+
+	return total - idle, total, nil
+}
@@ -0,0 +1,255 @@
+package load
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-zero-source/rollingwindow/rollingwindow"
+	"go-zero-source/util/clock"
+)
+
+const (
+	defaultWindow       = 5 * time.Second
+	defaultBuckets      = 50
+	defaultCpuThreshold = 900 // 90%
+	// 丢弃请求后的冷却时间，冷却期内即使 cpu 回落也继续按过载判断，避免抖动
+	coolOffDuration = time.Second
+	// 平均并发数的平滑系数
+	flyingBeta = 0.9
+	// 没有统计数据时的默认最小耗时（微秒）
+	defaultMinRt = float64(time.Second / time.Microsecond)
+)
+
+// ErrServiceOverloaded 服务过载时返回错误
+var ErrServiceOverloaded = errors.New("service overloaded")
+
+// Promise 与熔断器的 Promise 方法一致，请求结束时必须调用其中之一
+type Promise interface {
+	// Accept 告知降载器请求成功
+	Accept()
+	// Reject 告知降载器请求失败
+	Reject(reason string)
+}
+
+// Shedder 参考 BBR 的自适应降载器
+// 1. 滑动窗口统计每个桶的放行请求数与平均耗时，最大吞吐 × 最小耗时 即系统能承载的最大并发
+// 2. cpu 使用率超过阈值（或处于冷却期）且当前并发超过最大并发时，拒绝请求
+// 3. 可选设置并发数硬上限，超过时直接拒绝
+type Shedder struct {
+	cpuThreshold int64
+	maxInFlight  int64   // 并发数硬上限，0 表示不限制
+	windowScale  float64 // 每秒的桶数量
+	clock        clock.Clock
+	cpu          func() int64
+
+	flying        int64 // 当前并发数
+	avgFlying     float64
+	avgFlyingLock sync.Mutex
+	dropTime      int64 // 上次丢弃请求的时间（纳秒），0 表示从未丢弃
+
+	passCounter *rollingwindow.Window // 每个桶放行的请求数
+	rtCounter   *rollingwindow.Window // 每个桶请求的耗时（微秒）
+}
+
+type Option func(opt *options)
+
+type options struct {
+	window       time.Duration
+	buckets      int
+	cpuThreshold int64
+	maxInFlight  int64
+	clock        clock.Clock
+}
+
+// WithWindow 统计窗口长度，默认 5s
+func WithWindow(window time.Duration) Option {
+	return func(opt *options) {
+		opt.window = window
+	}
+}
+
+// WithBuckets 统计窗口的桶数量，默认 50
+func WithBuckets(buckets int) Option {
+	return func(opt *options) {
+		opt.buckets = buckets
+	}
+}
+
+// WithCpuThreshold cpu 使用率阈值，取值 [0, 1000]，默认 900
+func WithCpuThreshold(threshold int64) Option {
+	return func(opt *options) {
+		opt.cpuThreshold = threshold
+	}
+}
+
+// WithMaxInFlight 并发数硬上限，默认不限制
+func WithMaxInFlight(n int64) Option {
+	return func(opt *options) {
+		opt.maxInFlight = n
+	}
+}
+
+// WithClock 指定时钟，测试时可以传入 clocktest.FakeClock
+func WithClock(c clock.Clock) Option {
+	return func(opt *options) {
+		opt.clock = c
+	}
+}
+
+func NewShedder(opts ...Option) *Shedder {
+	o := options{
+		window:       defaultWindow,
+		buckets:      defaultBuckets,
+		cpuThreshold: defaultCpuThreshold,
+		clock:        clock.Default,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	// 桶数量或桶间隔非法时使用默认值，避免除零或时间轮无法轮转
+	if o.buckets <= 0 {
+		o.buckets = defaultBuckets
+	}
+	if o.window/time.Duration(o.buckets) <= 0 {
+		o.window = defaultWindow
+		o.buckets = defaultBuckets
+	}
+
+	startCpuSampler()
+
+	interval := o.window / time.Duration(o.buckets)
+	// 当前桶的数据不完整，统计时忽略
+	windowOpts := []rollingwindow.WindowOption{
+		rollingwindow.WithSize(o.buckets),
+		rollingwindow.WithInterval(interval),
+		rollingwindow.WithIgnoreCurrent(),
+		rollingwindow.WithClock(o.clock),
+	}
+	return &Shedder{
+		cpuThreshold: o.cpuThreshold,
+		maxInFlight:  o.maxInFlight,
+		windowScale:  float64(time.Second) / float64(interval),
+		clock:        o.clock,
+		cpu:          CpuUsage,
+		passCounter:  rollingwindow.NewWindow(windowOpts...),
+		rtCounter:    rollingwindow.NewWindow(windowOpts...),
+	}
+}
+
+// Allow 判断请求是否放行，放行时返回 Promise，过载时返回 ErrServiceOverloaded
+func (s *Shedder) Allow() (Promise, error) {
+	if s.shouldDrop() {
+		atomic.StoreInt64(&s.dropTime, s.clock.Now().UnixNano())
+		return nil, ErrServiceOverloaded
+	}
+
+	s.addFlying(1)
+	return &promise{
+		start:   s.clock.Now(),
+		shedder: s,
+	}, nil
+}
+
+func (s *Shedder) shouldDrop() bool {
+	flying := atomic.LoadInt64(&s.flying)
+	if s.maxInFlight > 0 && flying >= s.maxInFlight {
+		return true
+	}
+
+	if !s.systemOverloaded() && !s.stillHot() {
+		return false
+	}
+
+	return s.highThru(flying)
+}
+
+// systemOverloaded cpu 使用率是否超过阈值
+func (s *Shedder) systemOverloaded() bool {
+	return s.cpu() >= s.cpuThreshold
+}
+
+// stillHot 是否处于丢弃请求后的冷却期
+func (s *Shedder) stillHot() bool {
+	dropTime := atomic.LoadInt64(&s.dropTime)
+	if dropTime == 0 {
+		return false
+	}
+
+	return s.clock.Now().UnixNano()-dropTime < int64(coolOffDuration)
+}
+
+// highThru 当前并发与平均并发是否都超过了最大并发
+func (s *Shedder) highThru(flying int64) bool {
+	s.avgFlyingLock.Lock()
+	avgFlying := s.avgFlying
+	s.avgFlyingLock.Unlock()
+
+	maxFlight := s.maxFlight()
+	return avgFlying > maxFlight && float64(flying) > maxFlight
+}
+
+// maxFlight 系统能承载的最大并发：每秒最大吞吐 × 最小耗时
+func (s *Shedder) maxFlight() float64 {
+	maxQPS := float64(s.maxPass()) * s.windowScale
+	maxFlight := maxQPS * s.minRt() / float64(time.Second/time.Microsecond)
+	return math.Max(maxFlight, 1)
+}
+
+// maxPass 单个桶的最大放行请求数
+func (s *Shedder) maxPass() int64 {
+	var result int64 = 1
+	s.passCounter.ReduceFunc(func(b rollingwindow.Bucket) {
+		if b.Sum > result {
+			result = b.Sum
+		}
+	})
+
+	return result
+}
+
+// minRt 单个桶的最小平均耗时（微秒）
+func (s *Shedder) minRt() float64 {
+	result := defaultMinRt
+	s.rtCounter.ReduceFunc(func(b rollingwindow.Bucket) {
+		if b.Count > 0 {
+			result = math.Min(result, b.Avg())
+		}
+	})
+
+	return result
+}
+
+func (s *Shedder) addFlying(delta int64) {
+	flying := atomic.AddInt64(&s.flying, delta)
+	// 请求结束时更新平均并发数，与 go-zero 一致，只在请求结束时更新，避免突发请求直接拉高平均值
+	if delta < 0 {
+		s.avgFlyingLock.Lock()
+		s.avgFlying = s.avgFlying*flyingBeta + float64(flying)*(1-flyingBeta)
+		s.avgFlyingLock.Unlock()
+	}
+}
+
+type promise struct {
+	start   time.Time
+	shedder *Shedder
+}
+
+// Accept 请求成功，记录耗时与放行数
+func (p *promise) Accept() {
+	rt := p.shedder.clock.Since(p.start).Microseconds()
+	p.shedder.addFlying(-1)
+	// 耗时至少记为 1 微秒，避免最小耗时为 0
+	if rt < 1 {
+		rt = 1
+	}
+	p.shedder.rtCounter.Add(rt)
+	p.shedder.passCounter.Add(1)
+}
+
+// Reject 请求失败，失败的请求不计入吞吐与耗时
+func (p *promise) Reject(_ string) {
+	p.shedder.addFlying(-1)
+}
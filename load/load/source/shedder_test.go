@@ -0,0 +1,101 @@
+package load
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"go-zero-source/util/clock/clocktest"
+)
+
+func newTestShedder(cpu *int64, opts ...Option) (*Shedder, *clocktest.FakeClock) {
+	clk := clocktest.NewFakeClock(time.Now())
+	opts = append([]Option{WithWindow(time.Second), WithBuckets(10), WithClock(clk)}, opts...)
+	s := NewShedder(opts...)
+	s.cpu = func() int64 {
+		return *cpu
+	}
+
+	return s, clk
+}
+
+func TestShedderOverloaded(t *testing.T) {
+	var cpu int64
+	s, clk := newTestShedder(&cpu)
+
+	// 串行请求，每个耗时 10ms，每个桶放行 10 个，最大并发约为 1
+	for i := 0; i < 50; i++ {
+		p, err := s.Allow()
+		assert.Nil(t, err)
+		clk.Advance(10 * time.Millisecond)
+		p.Accept()
+	}
+	assert.InDelta(t, 1, s.maxFlight(), 0.2)
+
+	// cpu 未过载时，并发超过最大并发也放行
+	var promises []Promise
+	for i := 0; i < 10; i++ {
+		p, err := s.Allow()
+		assert.Nil(t, err)
+		promises = append(promises, p)
+	}
+
+	// cpu 过载，平均并发与当前并发都超过最大并发时拒绝
+	cpu = 950
+	promises[0].Accept()
+	promises[1].Reject("fail")
+	_, err := s.Allow()
+	assert.Equal(t, ErrServiceOverloaded, err)
+
+	// cpu 回落，冷却期内仍然拒绝
+	cpu = 0
+	_, err = s.Allow()
+	assert.Equal(t, ErrServiceOverloaded, err)
+
+	clk.Advance(coolOffDuration)
+	p, err := s.Allow()
+	assert.Nil(t, err)
+	p.Accept()
+}
+
+func TestShedderMaxInFlight(t *testing.T) {
+	var cpu int64
+	s, _ := newTestShedder(&cpu, WithMaxInFlight(2))
+
+	p1, err := s.Allow()
+	assert.Nil(t, err)
+	_, err = s.Allow()
+	assert.Nil(t, err)
+	_, err = s.Allow()
+	assert.Equal(t, ErrServiceOverloaded, err)
+
+	p1.Reject("fail")
+	_, err = s.Allow()
+	assert.Nil(t, err)
+}
+
+func TestShedderInvalidWindow(t *testing.T) {
+	// 非法的桶数量与窗口长度回退为默认值 5s / 50 个桶
+	for _, opts := range [][]Option{
+		{WithBuckets(0)},
+		{WithBuckets(-1)},
+		{WithWindow(time.Nanosecond)},
+	} {
+		var s *Shedder
+		assert.NotPanics(t, func() {
+			s = NewShedder(opts...)
+		})
+		assert.Equal(t, float64(defaultBuckets)/defaultWindow.Seconds(), s.windowScale)
+	}
+}
+
+func TestParseCpuStat(t *testing.T) {
+	busy, total, err := parseCpuStat("cpu  100 10 50 800 20 5 5 10 30 0\ncpu0 1 2 3 4 5 6 7 8 9 0\n")
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1000), total)
+	assert.Equal(t, uint64(180), busy)
+
+	_, _, err = parseCpuStat("intr 1 2 3")
+	assert.Equal(t, errInvalidCpuStat, err)
+}
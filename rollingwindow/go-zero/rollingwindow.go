@@ -4,7 +4,7 @@ import (
 	"sync"
 	"time"
 
-	"github.com/zeromicro/go-zero/core/timex"
+	"go-zero-source/util/clock"
 )
 
 type (
@@ -24,7 +24,9 @@ type (
 		// 最后写入桶的时间
 		// 通过记录该值，每次写入时，能快速算出经过了多少偏移量
 		// 而无需每次窗口刻度都进行计算
-		lastTime time.Duration
+		lastTime time.Time
+		// 时钟，默认为系统时间
+		clock clock.Clock
 	}
 )
 
@@ -37,11 +39,12 @@ func NewRollingWindow(size int, interval time.Duration, opts ...RollingWindowOpt
 		size:     size,
 		win:      newWindow(size),
 		interval: interval,
-		lastTime: timex.Now(),
+		clock:    clock.Default,
 	}
 	for _, opt := range opts {
 		opt(w)
 	}
+	w.lastTime = w.clock.Now()
 	return w
 }
 
@@ -84,7 +87,7 @@ func (rw *RollingWindow) Reduce(fn func(b *Bucket)) {
 // 通过 lastTime 计算经过了多少偏移量
 func (rw *RollingWindow) span() int {
 	// 计算偏移量
-	offset := int(timex.Since(rw.lastTime) / rw.interval)
+	offset := int(rw.clock.Since(rw.lastTime) / rw.interval)
 	// 判断是否在窗口范围内，如果在窗口范围内直接返回
 	if 0 <= offset && offset < rw.size {
 		return offset
@@ -113,9 +116,9 @@ func (rw *RollingWindow) updateOffset() {
 
 	// 得到新的桶偏移量，新桶此时已经被前面重置过了
 	rw.offset = (offset + span) % rw.size
-	now := timex.Now()
+	now := rw.clock.Now()
 	// 与时间刻度对齐，保证 lastTime 始终是 interval 的整数倍
-	rw.lastTime = now - (now-rw.lastTime)%rw.interval
+	rw.lastTime = now.Add(-(now.Sub(rw.lastTime) % rw.interval))
 }
 
 // Bucket 桶
@@ -183,3 +186,10 @@ func IgnoreCurrentBucket() RollingWindowOption {
 		w.ignoreCurrent = true
 	}
 }
+
+// WithClock 指定时钟，测试时可以传入 clocktest.FakeClock
+func WithClock(c clock.Clock) RollingWindowOption {
+	return func(w *RollingWindow) {
+		w.clock = c
+	}
+}
@@ -0,0 +1,72 @@
+package collection
+
+import "time"
+
+type (
+	// WindowSnapshot 滑动窗口快照，只包含值类型，可以直接序列化后发送到其他进程
+	WindowSnapshot struct {
+		Interval time.Duration    // 滑动窗口刻度
+		Buckets  []BucketSnapshot // 未过期且非空的桶，按时间先后排列，包含当前桶
+	}
+
+	// BucketSnapshot 桶的快照
+	BucketSnapshot struct {
+		Start time.Time // 桶的起始时间
+		Sum   float64
+		Count int64
+	}
+)
+
+// Snapshot 获取滑动窗口快照
+func (rw *RollingWindow) Snapshot() WindowSnapshot {
+	rw.lock.RLock()
+	defer rw.lock.RUnlock()
+
+	s := WindowSnapshot{Interval: rw.interval}
+	span := rw.span()
+	// 未过期的桶的起始位置，与 Reduce 一致，但快照总是包含当前桶
+	start := (rw.offset + span + 1) % rw.size
+	for i := 0; i < rw.size-span; i++ {
+		idx := (start + i) % rw.size
+		b := rw.win.buckets[idx]
+		if b.Count == 0 {
+			continue
+		}
+
+		// 当前桶起始于 lastTime，往前每个桶早一个 interval
+		dist := (rw.offset - idx + rw.size) % rw.size
+		s.Buckets = append(s.Buckets, BucketSnapshot{
+			Start: rw.lastTime.Add(-time.Duration(dist) * rw.interval),
+			Sum:   b.Sum,
+			Count: b.Count,
+		})
+	}
+
+	return s
+}
+
+// Merge 将快照合并到当前滑动窗口
+// 按快照中桶的中点时间找到本地对应的桶，两边的桶边界或刻度不一致时也能对齐
+// 已滑出本地窗口的桶直接丢弃，晚于当前时间的桶（时钟偏差）归入当前桶
+func (rw *RollingWindow) Merge(s WindowSnapshot) {
+	rw.lock.Lock()
+	defer rw.lock.Unlock()
+
+	// 先更新偏移量，清理过期的桶
+	rw.updateOffset()
+	for _, b := range s.Buckets {
+		mid := b.Start.Add(s.Interval / 2)
+		var dist int
+		if behind := rw.lastTime.Sub(mid); behind > 0 {
+			// 向上取整：mid 落在 [lastTime - dist*interval, lastTime - (dist-1)*interval) 中
+			dist = int((behind + rw.interval - 1) / rw.interval)
+		}
+		if dist >= rw.size {
+			continue
+		}
+
+		bucket := rw.win.buckets[(rw.offset-dist+rw.size)%rw.size]
+		bucket.Sum += b.Sum
+		bucket.Count += b.Count
+	}
+}
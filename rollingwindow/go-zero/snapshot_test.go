@@ -0,0 +1,49 @@
+package collection
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"go-zero-source/util/clock/clocktest"
+)
+
+const interval = 500 * time.Millisecond
+
+func sum(rw *RollingWindow) (sum float64, count int64) {
+	rw.Reduce(func(b *Bucket) {
+		sum += b.Sum
+		count += b.Count
+	})
+	return
+}
+
+func TestRollingWindowMerge(t *testing.T) {
+	start := time.Now()
+	clk := clocktest.NewFakeClock(start)
+	remote := NewRollingWindow(3, interval, WithClock(clk))
+	remote.Add(1)
+	clk.Advance(interval)
+	remote.Add(2)
+
+	s := remote.Snapshot()
+	assert.Equal(t, []BucketSnapshot{
+		{Start: start, Sum: 1, Count: 1},
+		{Start: start.Add(interval), Sum: 2, Count: 1},
+	}, s.Buckets)
+
+	// 本地窗口晚半个刻度创建，桶的边界与远端错开
+	clk.Advance(interval / 2)
+	local := NewRollingWindow(3, interval, WithClock(clk))
+	local.Add(10)
+	local.Merge(s)
+	total, count := sum(local)
+	assert.Equal(t, float64(13), total)
+	assert.Equal(t, int64(3), count)
+
+	// 远端第一个桶按中点时间归入本地更早的桶，先于其他桶过期
+	clk.Advance(2 * interval)
+	total, _ = sum(local)
+	assert.Equal(t, float64(12), total)
+}
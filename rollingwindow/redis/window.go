@@ -0,0 +1,130 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"go-zero-source/util/clock"
+)
+
+const (
+	defaultSize     = 10
+	defaultInterval = 100 * time.Millisecond
+)
+
+var (
+	// 当前桶累加统计值，顺带清理过期桶并续期
+	// KEYS[1]: 窗口 key，ARGV[1]: 当前刻度，ARGV[2]: 统计值，ARGV[3]: 桶的数量，ARGV[4]: 过期时间(ms)
+	addScript = redis.NewScript(`
+redis.call("HINCRBY", KEYS[1], ARGV[1], ARGV[2])
+local oldest = tonumber(ARGV[1]) - tonumber(ARGV[3])
+for _, field in ipairs(redis.call("HKEYS", KEYS[1])) do
+	if tonumber(field) <= oldest then
+		redis.call("HDEL", KEYS[1], field)
+	end
+end
+redis.call("PEXPIRE", KEYS[1], ARGV[4])
+return 1
+`)
+
+	// 汇总未过期桶的统计值
+	// KEYS[1]: 窗口 key，ARGV[1]: 当前刻度，ARGV[2]: 桶的数量
+	reduceScript = redis.NewScript(`
+local current = tonumber(ARGV[1])
+local oldest = current - tonumber(ARGV[2])
+local kvs = redis.call("HGETALL", KEYS[1])
+local sum = 0
+for i = 1, #kvs, 2 do
+	local tick = tonumber(kvs[i])
+	if tick > oldest and tick <= current then
+		sum = sum + tonumber(kvs[i + 1])
+	end
+end
+return sum
+`)
+)
+
+// Window 基于 redis 的分布式滑动窗口，语义与 rollingwindow.Window 一致
+// 1. 窗口是一个 hash，field 为桶的刻度，value 为桶内统计值总和
+// 2. 刻度按 unix 时间对齐，所有实例共享同一组桶，要求各实例的时钟基本同步
+// 3. 写入和汇总都通过 lua 脚本完成，过期的桶在写入时清理，整个 key 在窗口时间内没有写入时自动过期
+type Window struct {
+	client   *redis.Client
+	key      string
+	size     int           // 桶的数量
+	interval time.Duration // 统计间隔
+	clock    clock.Clock
+}
+
+type WindowOption func(opt *Window)
+
+func WithSize(size int) WindowOption {
+	return func(opt *Window) {
+		opt.size = size
+	}
+}
+
+func WithInterval(interval time.Duration) WindowOption {
+	return func(opt *Window) {
+		opt.interval = interval
+	}
+}
+
+// WithClock 指定时钟，测试时可以传入 clocktest.FakeClock
+func WithClock(c clock.Clock) WindowOption {
+	return func(opt *Window) {
+		opt.clock = c
+	}
+}
+
+func NewWindow(client *redis.Client, key string, opts ...WindowOption) *Window {
+	w := &Window{
+		client:   client,
+		key:      key,
+		size:     defaultSize,
+		interval: defaultInterval,
+		clock:    clock.Default,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w
+}
+
+// Add 添加统计值，redis 出错时丢弃本次统计，需要感知错误时使用 AddCtx
+func (w *Window) Add(val int64) {
+	_ = w.AddCtx(context.Background(), val)
+}
+
+// AddCtx 添加统计值
+func (w *Window) AddCtx(ctx context.Context, val int64) error {
+	// 多留一个刻度，避免窗口边界上的桶提前过期
+	ttl := time.Duration(w.size+1) * w.interval
+	return addScript.Run(ctx, w.client, []string{w.key},
+		w.tick(), val, w.size, ttl.Milliseconds()).Err()
+}
+
+// Reduce 获取统计值，redis 出错时返回 0，需要感知错误时使用 ReduceCtx
+func (w *Window) Reduce() int64 {
+	sum, _ := w.ReduceCtx(context.Background())
+	return sum
+}
+
+// ReduceCtx 获取统计值
+func (w *Window) ReduceCtx(ctx context.Context) (int64, error) {
+	sum, err := reduceScript.Run(ctx, w.client, []string{w.key}, w.tick(), w.size).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+
+	return sum, err
+}
+
+// tick 当前所处刻度
+func (w *Window) tick() string {
+	return strconv.FormatInt(w.clock.Now().UnixNano()/int64(w.interval), 10)
+}
@@ -0,0 +1,79 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+
+	"go-zero-source/util/clock/clocktest"
+)
+
+const interval = 500 * time.Millisecond
+
+func newTestWindow(t *testing.T, opts ...WindowOption) (*Window, *clocktest.FakeClock, *miniredis.Miniredis) {
+	m := miniredis.RunT(t)
+	clk := clocktest.NewFakeClock(time.Unix(0, 0))
+	client := redis.NewClient(&redis.Options{Addr: m.Addr()})
+	opts = append([]WindowOption{WithInterval(interval), WithClock(clk)}, opts...)
+	return NewWindow(client, "window", opts...), clk, m
+}
+
+func TestWindowAdd(t *testing.T) {
+	r, clk, _ := newTestWindow(t, WithSize(3))
+	assert.Equal(t, int64(0), r.Reduce())
+	r.Add(1)
+	assert.Equal(t, int64(1), r.Reduce())
+	clk.Advance(interval)
+	r.Add(2)
+	r.Add(3)
+	assert.Equal(t, int64(6), r.Reduce())
+	clk.Advance(interval)
+	r.Add(4)
+	r.Add(5)
+	r.Add(6)
+	assert.Equal(t, int64(21), r.Reduce())
+	clk.Advance(interval)
+	r.Add(7)
+	assert.Equal(t, int64(27), r.Reduce())
+}
+
+func TestWindowShared(t *testing.T) {
+	r, clk, m := newTestWindow(t, WithSize(4))
+	// 另一个实例共享同一个 key
+	other := NewWindow(redis.NewClient(&redis.Options{Addr: m.Addr()}), "window",
+		WithSize(4), WithInterval(interval), WithClock(clk))
+
+	for i := 1; i <= 4; i++ {
+		assert.Nil(t, r.AddCtx(context.Background(), int64(i*10)))
+		assert.Nil(t, other.AddCtx(context.Background(), int64(i)))
+		clk.Advance(interval)
+	}
+	// 第一个桶过期
+	sum, err := other.ReduceCtx(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(99), sum)
+
+	// 写入时清理过期的桶，并续期整个 key
+	r.Add(0)
+	fields, err := m.HKeys("window")
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(fields))
+	assert.Equal(t, 5*interval, m.TTL("window"))
+
+	// key 过期后统计值归零
+	m.FastForward(5 * interval)
+	assert.Equal(t, int64(0), r.Reduce())
+}
+
+func TestWindowRedisDown(t *testing.T) {
+	r, _, m := newTestWindow(t)
+	m.Close()
+	assert.NotNil(t, r.AddCtx(context.Background(), 1))
+	_, err := r.ReduceCtx(context.Background())
+	assert.NotNil(t, err)
+	assert.Equal(t, int64(0), r.Reduce())
+}
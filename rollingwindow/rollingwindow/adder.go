@@ -0,0 +1,20 @@
+package rollingwindow
+
+// Adder 接收统计值的组件，Window、StripedWindow、Meter、DecayingAverage 都实现了该接口
+// 上层组件（熔断器、降载等）可以按需选择滑动窗口或平滑后的统计值
+type Adder interface {
+	Add(val int64)
+}
+
+// Multi 将统计值同时写入多个组件，便于在同一处埋点同时得到窗口统计与平滑统计
+func Multi(adders ...Adder) Adder {
+	return multiAdder(adders)
+}
+
+type multiAdder []Adder
+
+func (m multiAdder) Add(val int64) {
+	for _, a := range m {
+		a.Add(val)
+	}
+}
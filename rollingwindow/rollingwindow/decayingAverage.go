@@ -0,0 +1,56 @@
+package rollingwindow
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"go-zero-source/util/clock"
+)
+
+// DecayingAverage 随时间衰减的平均值，例如平均耗时
+// 每个统计值的权重为 e^(-Δt/tau)，Δt 为距今的时间，越旧的数据权重越低
+type DecayingAverage struct {
+	lock     sync.Mutex
+	tau      time.Duration // 衰减的时间常数
+	clock    clock.Clock
+	lastTime time.Time
+	sum      float64 // 加权后的统计值总和
+	weight   float64 // 权重总和
+}
+
+// NewDecayingAverage tau 为衰减的时间常数，经过 tau 后数据的权重衰减为原来的 1/e
+// 支持通过 WithClock 指定时钟
+func NewDecayingAverage(tau time.Duration, opts ...WindowOption) *DecayingAverage {
+	t := newTimeline(opts...)
+	return &DecayingAverage{
+		tau:      tau,
+		clock:    t.clock,
+		lastTime: t.lastTime,
+	}
+}
+
+// Add 添加统计值
+func (d *DecayingAverage) Add(val int64) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	now := d.clock.Now()
+	decay := math.Exp(-float64(now.Sub(d.lastTime)) / float64(d.tau))
+	d.lastTime = now
+	d.sum = d.sum*decay + float64(val)
+	d.weight = d.weight*decay + 1
+}
+
+// Value 当前的平均值
+// 没有新数据时，所有数据的权重等比例衰减，平均值保持不变
+func (d *DecayingAverage) Value() float64 {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.weight == 0 {
+		return 0
+	}
+
+	return d.sum / d.weight
+}
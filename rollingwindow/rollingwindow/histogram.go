@@ -0,0 +1,211 @@
+package rollingwindow
+
+import (
+	"math"
+	"math/bits"
+	"sync"
+	"time"
+)
+
+// 对数线性分桶：每个 2 的幂次区间再等分为 subBucketCount 份，相对误差不超过 1/subBucketCount
+const (
+	subBucketBits  = 4
+	subBucketCount = 1 << subBucketBits
+	// 覆盖 [0, math.MaxInt64] 所需的分桶数量
+	valueBuckets = (64 - subBucketBits) * subBucketCount
+)
+
+// Histogram 滑动窗口直方图，统计窗口内的耗时分布
+// 与 Window 共用桶的轮转逻辑，每个桶内是一份对数线性直方图
+type Histogram struct {
+	timeline
+	buckets []*histogramBucket
+	lock    sync.RWMutex
+}
+
+func NewHistogram(opts ...WindowOption) *Histogram {
+	h := &Histogram{
+		timeline: newTimeline(opts...),
+	}
+
+	h.buckets = make([]*histogramBucket, h.size)
+	for i := 0; i < h.size; i++ {
+		h.buckets[i] = newHistogramBucket()
+	}
+
+	return h
+}
+
+// Record 记录一次耗时，负数按 0 处理
+func (h *Histogram) Record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.current = h.rotate(func(idx int) {
+		h.buckets[idx].reset()
+	})
+	h.buckets[h.current].record(int64(d))
+}
+
+// Percentile 窗口内的 p 分位耗时，p 取值 [0, 100]
+func (h *Histogram) Percentile(p float64) time.Duration {
+	return h.Snapshot().Percentile(p)
+}
+
+// Mean 窗口内的平均耗时
+func (h *Histogram) Mean() time.Duration {
+	return h.Snapshot().Mean()
+}
+
+// Max 窗口内的最大耗时
+func (h *Histogram) Max() time.Duration {
+	return h.Snapshot().Max
+}
+
+// Snapshot 合并窗口内未过期的桶，得到一份只读快照
+// 需要同时读取多个分位值时，先取快照可以避免重复合并
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	s := HistogramSnapshot{counts: make([]int64, valueBuckets)}
+	h.each(func(idx int) {
+		b := h.buckets[idx]
+		if b.count == 0 {
+			return
+		}
+
+		if s.Count == 0 || b.min < int64(s.Min) {
+			s.Min = time.Duration(b.min)
+		}
+		if b.max > int64(s.Max) {
+			s.Max = time.Duration(b.max)
+		}
+		s.Count += b.count
+		s.Sum += time.Duration(b.sum)
+		for i, c := range b.counts {
+			s.counts[i] += c
+		}
+	})
+
+	return s
+}
+
+// HistogramSnapshot 直方图快照
+type HistogramSnapshot struct {
+	Count int64         // 记录次数
+	Sum   time.Duration // 耗时总和
+	Min   time.Duration // 最小耗时
+	Max   time.Duration // 最大耗时
+
+	counts []int64 // 各分桶的记录次数
+}
+
+// Percentile p 分位耗时，p 取值 [0, 100]
+// 返回所在分桶的上界，并限制在 [Min, Max] 之内，p 为 0 和 100 时分别精确等于 Min 和 Max
+func (s HistogramSnapshot) Percentile(p float64) time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	if p <= 0 {
+		return s.Min
+	}
+	if p >= 100 {
+		return s.Max
+	}
+
+	rank := int64(math.Ceil(p / 100 * float64(s.Count)))
+	var seen int64
+	for i, c := range s.counts {
+		seen += c
+		if seen < rank {
+			continue
+		}
+
+		v := time.Duration(bucketUpper(i))
+		if v > s.Max {
+			return s.Max
+		}
+		if v < s.Min {
+			return s.Min
+		}
+		return v
+	}
+
+	return s.Max
+}
+
+// Mean 平均耗时
+func (s HistogramSnapshot) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+
+	return s.Sum / time.Duration(s.Count)
+}
+
+// histogramBucket 直方图的一个时间桶
+type histogramBucket struct {
+	counts []int64
+	count  int64
+	sum    int64
+	min    int64
+	max    int64
+}
+
+func newHistogramBucket() *histogramBucket {
+	return &histogramBucket{counts: make([]int64, valueBuckets)}
+}
+
+func (b *histogramBucket) record(v int64) {
+	if b.count == 0 || v < b.min {
+		b.min = v
+	}
+	if v > b.max {
+		b.max = v
+	}
+	b.count++
+	b.sum += v
+	b.counts[bucketIndex(v)]++
+}
+
+// reset 重置当前桶，桶内没有数据时无需清理分桶
+func (b *histogramBucket) reset() {
+	if b.count == 0 {
+		return
+	}
+
+	for i := range b.counts {
+		b.counts[i] = 0
+	}
+	b.count = 0
+	b.sum = 0
+	b.min = 0
+	b.max = 0
+}
+
+// bucketIndex 计算值所在的分桶
+// 小于 subBucketCount 的值各占一个分桶，之后每个 2 的幂次区间占 subBucketCount 个分桶
+func bucketIndex(v int64) int {
+	if v < subBucketCount {
+		return int(v)
+	}
+
+	shift := bits.Len64(uint64(v)) - subBucketBits - 1
+	return (shift+1)*subBucketCount + int(uint64(v)>>shift) - subBucketCount
+}
+
+// bucketUpper 分桶能表示的最大值
+func bucketUpper(idx int) int64 {
+	if idx < subBucketCount {
+		return int64(idx)
+	}
+
+	shift := idx/subBucketCount - 1
+	mantissa := uint64(idx%subBucketCount + subBucketCount)
+	return int64((mantissa+1)<<shift - 1)
+}
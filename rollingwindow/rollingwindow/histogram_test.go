@@ -0,0 +1,62 @@
+package rollingwindow
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"go-zero-source/util/clock/clocktest"
+)
+
+func TestHistogramPercentile(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	h := NewHistogram(WithSize(3), WithInterval(duration), WithClock(clk))
+	assert.Equal(t, time.Duration(0), h.Percentile(99))
+
+	for i := 1; i <= 1000; i++ {
+		h.Record(time.Duration(i) * time.Millisecond)
+	}
+
+	s := h.Snapshot()
+	assert.Equal(t, int64(1000), s.Count)
+	assert.Equal(t, time.Millisecond, s.Min)
+	assert.Equal(t, time.Second, s.Max)
+	assert.Equal(t, time.Second, h.Max())
+	assert.Equal(t, 500500*time.Microsecond, h.Mean())
+
+	// 对数线性分桶的相对误差不超过 1/16
+	for _, p := range []float64{50, 90, 99, 99.9} {
+		want := float64(time.Duration(p*10) * time.Millisecond)
+		assert.InEpsilon(t, want, float64(s.Percentile(p)), 1.0/subBucketCount)
+	}
+	assert.Equal(t, s.Min, s.Percentile(0))
+	assert.Equal(t, s.Max, s.Percentile(100))
+}
+
+func TestHistogramRotate(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	h := NewHistogram(WithSize(2), WithInterval(duration), WithClock(clk))
+	h.Record(time.Second)
+	elapse(clk)
+	h.Record(time.Millisecond)
+	assert.Equal(t, time.Second, h.Max())
+
+	elapse(clk)
+	h.Record(time.Millisecond)
+	// 第一个桶过期
+	assert.Equal(t, time.Millisecond, h.Max())
+	assert.Equal(t, int64(2), h.Snapshot().Count)
+}
+
+func TestBucketIndex(t *testing.T) {
+	for _, v := range []int64{0, 1, 15, 16, 17, 31, 32, 33, 1000, 123456789, math.MaxInt64} {
+		idx := bucketIndex(v)
+		assert.Less(t, idx, valueBuckets)
+		assert.GreaterOrEqual(t, bucketUpper(idx), v)
+		if idx > 0 {
+			assert.Less(t, bucketUpper(idx-1), v)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package rollingwindow
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"go-zero-source/util/clock"
+)
+
+const defaultTick = 5 * time.Second
+
+// Meter 速率计，统计 1/5/15 分钟的指数加权移动平均速率（每秒）
+// 与滑动窗口不同，旧数据的权重随时间指数衰减，不会因为桶过期而跳变
+// 衰减的刻度由 WithInterval 指定，默认 5s；不启动后台协程，读写时按经过的刻度补算衰减
+type Meter struct {
+	lock      sync.Mutex
+	tick      time.Duration
+	clock     clock.Clock
+	start     time.Time
+	lastTick  time.Time
+	count     int64 // 统计值总和
+	uncounted int64 // 当前刻度内尚未计入平均值的统计值
+	m1        ewma
+	m5        ewma
+	m15       ewma
+}
+
+func NewMeter(opts ...WindowOption) *Meter {
+	t := newTimeline(append([]WindowOption{WithInterval(defaultTick)}, opts...)...)
+	// 刻度非法时使用默认值，避免补算刻度时除零
+	if t.interval <= 0 {
+		t.interval = defaultTick
+	}
+
+	return &Meter{
+		tick:     t.interval,
+		clock:    t.clock,
+		start:    t.lastTime,
+		lastTick: t.lastTime,
+		m1:       newEWMA(time.Minute, t.interval),
+		m5:       newEWMA(5*time.Minute, t.interval),
+		m15:      newEWMA(15*time.Minute, t.interval),
+	}
+}
+
+// Add 添加统计值
+func (m *Meter) Add(val int64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.tickIfNeeded()
+	m.count += val
+	m.uncounted += val
+}
+
+// Count 统计值总和
+func (m *Meter) Count() int64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.count
+}
+
+// Rate1 1 分钟平均速率
+func (m *Meter) Rate1() float64 {
+	return m.rate(&m.m1)
+}
+
+// Rate5 5 分钟平均速率
+func (m *Meter) Rate5() float64 {
+	return m.rate(&m.m5)
+}
+
+// Rate15 15 分钟平均速率
+func (m *Meter) Rate15() float64 {
+	return m.rate(&m.m15)
+}
+
+// RateMean 从创建至今的平均速率
+func (m *Meter) RateMean() float64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	elapsed := m.clock.Since(m.start)
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return float64(m.count) / elapsed.Seconds()
+}
+
+func (m *Meter) rate(e *ewma) float64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.tickIfNeeded()
+	return e.rate
+}
+
+// tickIfNeeded 补算经过的刻度
+// 第一个刻度计入 uncounted，其余刻度没有新数据，只做衰减
+func (m *Meter) tickIfNeeded() {
+	ticks := int(m.clock.Since(m.lastTick) / m.tick)
+	if ticks <= 0 {
+		return
+	}
+
+	m.lastTick = m.lastTick.Add(time.Duration(ticks) * m.tick)
+	instant := float64(m.uncounted) / m.tick.Seconds()
+	m.uncounted = 0
+	m.m1.update(instant, ticks)
+	m.m5.update(instant, ticks)
+	m.m15.update(instant, ticks)
+}
+
+// ewma 指数加权移动平均
+type ewma struct {
+	alpha       float64 // 每个刻度新数据的权重
+	rate        float64
+	initialized bool
+}
+
+// newEWMA 平均周期为 period，刻度为 tick
+func newEWMA(period, tick time.Duration) ewma {
+	return ewma{alpha: 1 - math.Exp(-float64(tick)/float64(period))}
+}
+
+// update 推进 ticks 个刻度，instant 为第一个刻度内的瞬时速率
+func (e *ewma) update(instant float64, ticks int) {
+	if e.initialized {
+		e.rate += e.alpha * (instant - e.rate)
+	} else {
+		// 第一个刻度直接取瞬时速率，避免从 0 缓慢爬升
+		e.rate = instant
+		e.initialized = true
+	}
+
+	// 剩余的刻度没有新数据，直接按衰减系数计算
+	if ticks > 1 {
+		e.rate *= math.Pow(1-e.alpha, float64(ticks-1))
+	}
+}
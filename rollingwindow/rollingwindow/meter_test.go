@@ -0,0 +1,69 @@
+package rollingwindow
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"go-zero-source/util/clock/clocktest"
+)
+
+func TestMeter(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	m := NewMeter(WithClock(clk))
+	assert.Equal(t, float64(0), m.Rate1())
+
+	// 第一个刻度内 5s 添加 60，速率 12/s
+	m.Add(60)
+	clk.Advance(defaultTick)
+	assert.Equal(t, float64(12), m.Rate1())
+	assert.Equal(t, float64(12), m.Rate5())
+	assert.Equal(t, float64(12), m.Rate15())
+	assert.Equal(t, float64(12), m.RateMean())
+
+	// 一分钟没有新数据，1 分钟速率衰减为原来的 1/e
+	clk.Advance(time.Minute)
+	assert.InDelta(t, 12*math.Exp(-1), m.Rate1(), 1e-9)
+	assert.InDelta(t, 12*math.Exp(-0.2), m.Rate5(), 1e-9)
+	assert.Equal(t, int64(60), m.Count())
+}
+
+func TestMeterInvalidTick(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	// 刻度非法时回退为默认的 5s
+	m := NewMeter(WithClock(clk), WithInterval(0))
+	m.Add(60)
+	clk.Advance(defaultTick)
+	assert.Equal(t, float64(12), m.Rate1())
+}
+
+func TestDecayingAverage(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	d := NewDecayingAverage(time.Second, WithClock(clk))
+	assert.Equal(t, float64(0), d.Value())
+
+	d.Add(10)
+	assert.Equal(t, float64(10), d.Value())
+
+	clk.Advance(time.Second)
+	// 没有新数据时平均值不变
+	assert.Equal(t, float64(10), d.Value())
+
+	d.Add(20)
+	decay := math.Exp(-1)
+	assert.InDelta(t, (10*decay+20)/(decay+1), d.Value(), 1e-9)
+}
+
+func TestMulti(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	w := NewWindow(WithClock(clk))
+	m := NewMeter(WithClock(clk))
+
+	a := Multi(w, m)
+	a.Add(3)
+	a.Add(4)
+	assert.Equal(t, int64(7), w.Reduce())
+	assert.Equal(t, int64(7), m.Count())
+}
@@ -12,42 +12,18 @@ const (
 
 // Window 滑动窗口
 type Window struct {
-	buckets  []*bucket     // 桶
-	size     int           // 桶的数量
-	interval time.Duration // 统计间隔
-	lock     sync.RWMutex
-	lastTime time.Time // 上次更新时间
-	current  int       // 当前所处桶
-}
-
-type WindowOption func(opt *Window)
-
-func WithSize(size int) WindowOption {
-	return func(opt *Window) {
-		opt.size = size
-	}
-}
-
-func WithInterval(interval time.Duration) WindowOption {
-	return func(opt *Window) {
-		opt.interval = interval
-	}
+	timeline
+	buckets []*Bucket // 桶
+	lock    sync.RWMutex
 }
 
 func NewWindow(opts ...WindowOption) *Window {
 	window := &Window{
-		size:     defaultSize,
-		interval: defaultInterval,
-		lastTime: time.Now(),
-		current:  0,
-	}
-
-	for _, opt := range opts {
-		opt(window)
+		timeline: newTimeline(opts...),
 	}
 
 	// 初始化桶
-	window.buckets = make([]*bucket, window.size)
+	window.buckets = make([]*Bucket, window.size)
 	for i := 0; i < window.size; i++ {
 		window.buckets[i] = newBucket()
 	}
@@ -78,81 +54,108 @@ func (w *Window) Reduce() int64 {
 	w.lock.RLock()
 	defer w.lock.RUnlock()
 
-	// 计算偏移量，偏移过的桶都是已过期的
-	offset := w.span()
-	// 全部桶都过期了，直接返回 0
-	if offset == w.size {
-		return 0
-	}
-
 	// 计算剩余桶的总统计值
 	var sum int64 = 0
-
-	// 需要计算的桶总数：桶的总数 - 过期桶数量
-	total := w.size - offset
-	// 未过期的桶的起始位置
-	start := (w.current + offset + 1) % w.size
-	for i := 0; i < total; i++ {
-		idx := (start + i) % w.size
-		sum += w.buckets[idx].val
-	}
+	w.each(func(idx int) {
+		sum += w.buckets[idx].Sum
+	})
 
 	return sum
 }
 
-// 计算当前所处桶位置
-func (w *Window) currentBucket() int {
-	// 计算偏移量
-	offset := w.span()
-	// 没动，直接返回当前桶位置
-	if offset <= 0 {
-		return w.current
-	}
+// ReduceFunc 按时间先后遍历未过期的桶
+func (w *Window) ReduceFunc(fn func(b Bucket)) {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
 
-	// 偏移量，偏移经过的位置都是已过期的桶，需要将 (w.current, w.current+offset] 置为 0
+	w.each(func(idx int) {
+		fn(*w.buckets[idx])
+	})
+}
 
-	// 将已过期的桶置为0
-	old := w.current + 1
-	for i := 0; i < offset; i++ {
-		// 取余为了循环
-		idx := (old + i) % w.size
-		w.buckets[idx].reset()
-	}
+// Count 窗口内的统计次数
+func (w *Window) Count() int64 {
+	var count int64
+	w.ReduceFunc(func(b Bucket) {
+		count += b.Count
+	})
 
-	// 计算新的当前桶位置
-	w.current = (w.current + offset) % w.size
+	return count
+}
+
+// RatePerSecond 窗口内每秒的统计值，例如统计请求数时即为 QPS
+func (w *Window) RatePerSecond() float64 {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
 
-	// 更新上次更新时间
-	w.lastTime = time.Now()
+	// 统计值与窗口长度在同一次遍历中计算，保证来自同一个窗口
+	var sum int64
+	length := w.each(func(idx int) {
+		sum += w.buckets[idx].Sum
+	})
+	if length <= 0 {
+		return 0
+	}
 
-	return w.current
+	return float64(sum) / length.Seconds()
 }
 
-// 计算偏移量
-func (w *Window) span() int {
-	offset := int(time.Since(w.lastTime) / w.interval)
-	if 0 <= offset && offset <= w.size {
-		return offset
-	}
+// 计算当前所处桶位置
+func (w *Window) currentBucket() int {
+	return w.rotate(func(idx int) {
+		w.buckets[idx].reset()
+	})
+}
 
-	// 最多计算一圈
-	return w.size
+// Bucket 具体桶
+type Bucket struct {
+	Sum   int64 // 统计值总和
+	Count int64 // 统计次数
+	Min   int64 // 最小统计值
+	Max   int64 // 最大统计值
 }
 
-// bucket 具体桶
-type bucket struct {
-	val int64 // 统计数据
+func newBucket() *Bucket {
+	return new(Bucket)
 }
 
-func newBucket() *bucket {
-	return new(bucket)
+// Avg 桶内的平均统计值
+func (b Bucket) Avg() float64 {
+	if b.Count == 0 {
+		return 0
+	}
+
+	return float64(b.Sum) / float64(b.Count)
 }
 
 // reset 重置当前桶
-func (b *bucket) reset() {
-	b.val = 0
+func (b *Bucket) reset() {
+	*b = Bucket{}
 }
 
-func (b *bucket) add(val int64) {
-	b.val += val
+func (b *Bucket) add(val int64) {
+	if b.Count == 0 || val < b.Min {
+		b.Min = val
+	}
+	if b.Count == 0 || val > b.Max {
+		b.Max = val
+	}
+	b.Sum += val
+	b.Count++
+}
+
+// merge 合并另一个桶的统计值
+func (b *Bucket) merge(o Bucket) {
+	if o.Count == 0 {
+		return
+	}
+
+	if b.Count == 0 || o.Min < b.Min {
+		b.Min = o.Min
+	}
+	if b.Count == 0 || o.Max > b.Max {
+		b.Max = o.Max
+	}
+	b.Sum += o.Sum
+	b.Count += o.Count
 }
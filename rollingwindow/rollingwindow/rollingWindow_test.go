@@ -2,6 +2,8 @@ package rollingwindow
 
 import (
 	"github.com/stretchr/testify/assert"
+	"go-zero-source/util/clock/clocktest"
+	"sync"
 	"testing"
 	"time"
 )
@@ -9,34 +11,120 @@ import (
 const duration = time.Millisecond * 500
 
 func TestRollingWindowAdd(t *testing.T) {
-	r := NewWindow(WithSize(3), WithInterval(duration))
+	clk := clocktest.NewFakeClock(time.Now())
+	r := NewWindow(WithSize(3), WithInterval(duration), WithClock(clk))
 	assert.Equal(t, int64(0), r.Reduce())
 	r.Add(1)
 	assert.Equal(t, int64(1), r.Reduce())
-	elapse()
+	elapse(clk)
 	r.Add(2)
 	r.Add(3)
 	assert.Equal(t, int64(6), r.Reduce())
-	elapse()
+	elapse(clk)
 	r.Add(4)
 	r.Add(5)
 	r.Add(6)
 	assert.Equal(t, int64(21), r.Reduce())
-	elapse()
+	elapse(clk)
 	r.Add(7)
 	assert.Equal(t, int64(27), r.Reduce())
 }
 
 func TestRollingWindowReduce(t *testing.T) {
-	r := NewWindow(WithSize(4), WithInterval(duration))
+	clk := clocktest.NewFakeClock(time.Now())
+	r := NewWindow(WithSize(4), WithInterval(duration), WithClock(clk))
 	for i := 1; i <= 4; i++ {
 		r.Add(int64(i * 10))
-		elapse()
+		elapse(clk)
 	}
 	// 第一个桶过期
 	assert.Equal(t, int64(90), r.Reduce())
 }
 
-func elapse() {
-	time.Sleep(duration)
+// elapse 推进一个桶的时间
+func elapse(clk *clocktest.FakeClock) {
+	clk.Advance(duration)
+}
+
+func TestRollingWindowReduceFunc(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	r := NewWindow(WithSize(3), WithInterval(duration), WithClock(clk))
+	r.Add(1)
+	r.Add(5)
+	elapse(clk)
+	r.Add(-2)
+
+	var buckets []Bucket
+	r.ReduceFunc(func(b Bucket) {
+		if b.Count > 0 {
+			buckets = append(buckets, b)
+		}
+	})
+	assert.Equal(t, []Bucket{
+		{Sum: 6, Count: 2, Min: 1, Max: 5},
+		{Sum: -2, Count: 1, Min: -2, Max: -2},
+	}, buckets)
+	assert.Equal(t, float64(3), buckets[0].Avg())
+	assert.Equal(t, int64(3), r.Count())
+	// 窗口长度 1.5s
+	assert.InDelta(t, float64(4)/1.5, r.RatePerSecond(), 1e-9)
+}
+
+func TestRollingWindowIgnoreCurrent(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	r := NewWindow(WithSize(3), WithInterval(duration), WithClock(clk), WithIgnoreCurrent())
+	r.Add(1)
+	assert.Equal(t, int64(0), r.Reduce())
+	elapse(clk)
+	r.Add(2)
+	assert.Equal(t, int64(1), r.Reduce())
+	// 窗口长度 1s
+	assert.InDelta(t, float64(1), r.RatePerSecond(), 1e-9)
+
+	// 当前时间已离开最后写入的桶，该桶数据完整，参与统计
+	elapse(clk)
+	assert.Equal(t, int64(3), r.Reduce())
+	assert.InDelta(t, float64(3)/1.5, r.RatePerSecond(), 1e-9)
+}
+
+func TestRollingWindowRotateFromLastTime(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	r := NewWindow(WithSize(3), WithInterval(duration), WithClock(clk))
+	r.Add(1)
+
+	// 与 go-zero 一致，按距离上次轮转的时间计算偏移，轮转时间不对齐到桶的刻度
+	clk.Advance(duration + duration/2)
+	r.Add(1)
+	// 距离上次轮转不足一个 interval，仍在当前桶
+	clk.Advance(duration * 4 / 5)
+	r.Add(1)
+
+	var counts []int64
+	r.ReduceFunc(func(b Bucket) {
+		if b.Count > 0 {
+			counts = append(counts, b.Count)
+		}
+	})
+	assert.Equal(t, []int64{1, 2}, counts)
+}
+
+func TestRollingWindowRatePerSecondConcurrent(t *testing.T) {
+	r := NewWindow(WithSize(3), WithInterval(time.Millisecond), WithIgnoreCurrent())
+
+	// 与 Add 并发调用，-race 下不能有数据竞争
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			r.Add(1)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			assert.True(t, r.RatePerSecond() >= 0)
+		}
+	}()
+	wg.Wait()
 }
@@ -0,0 +1,54 @@
+package rollingwindow
+
+import "time"
+
+// WindowSnapshot 滑动窗口快照，只包含值类型，可以直接序列化后发送到其他进程
+type WindowSnapshot struct {
+	Interval time.Duration    // 统计间隔
+	Buckets  []BucketSnapshot // 未过期且非空的桶，按时间先后排列，包含当前桶
+}
+
+// BucketSnapshot 桶的快照
+type BucketSnapshot struct {
+	Start time.Time // 桶的起始时间
+	Bucket
+}
+
+// Snapshot 获取窗口快照
+func (w *Window) Snapshot() WindowSnapshot {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	s := WindowSnapshot{Interval: w.interval}
+	w.walk(false, func(idx int) {
+		if w.buckets[idx].Count == 0 {
+			return
+		}
+
+		s.Buckets = append(s.Buckets, BucketSnapshot{
+			Start:  w.bucketStart(idx),
+			Bucket: *w.buckets[idx],
+		})
+	})
+
+	return s
+}
+
+// Merge 将快照合并到当前窗口
+// 按快照中桶的中点时间找到本地对应的桶，两边的桶边界或统计间隔不一致时也能对齐
+// 已滑出本地窗口的桶直接丢弃，晚于当前时间的桶（时钟偏差）归入当前桶
+func (w *Window) Merge(s WindowSnapshot) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	// 先轮转到当前时间，清理过期的桶
+	w.current = w.currentBucket()
+	for _, b := range s.Buckets {
+		idx, ok := w.bucketAt(b.Start.Add(s.Interval / 2))
+		if !ok {
+			continue
+		}
+
+		w.buckets[idx].merge(b.Bucket)
+	}
+}
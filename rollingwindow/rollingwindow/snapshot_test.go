@@ -0,0 +1,71 @@
+package rollingwindow
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"go-zero-source/util/clock/clocktest"
+)
+
+func TestWindowSnapshot(t *testing.T) {
+	start := time.Now()
+	clk := clocktest.NewFakeClock(start)
+	r := NewWindow(WithSize(3), WithInterval(duration), WithClock(clk))
+	r.Add(1)
+	r.Add(3)
+	elapse(clk)
+	r.Add(2)
+
+	s := r.Snapshot()
+	assert.Equal(t, WindowSnapshot{
+		Interval: duration,
+		Buckets: []BucketSnapshot{
+			{Start: start, Bucket: Bucket{Sum: 4, Count: 2, Min: 1, Max: 3}},
+			{Start: start.Add(duration), Bucket: Bucket{Sum: 2, Count: 1, Min: 2, Max: 2}},
+		},
+	}, s)
+
+	// 快照可以序列化
+	data, err := json.Marshal(s)
+	assert.Nil(t, err)
+	var decoded WindowSnapshot
+	assert.Nil(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, len(s.Buckets), len(decoded.Buckets))
+	for i := range s.Buckets {
+		assert.True(t, s.Buckets[i].Start.Equal(decoded.Buckets[i].Start))
+		assert.Equal(t, s.Buckets[i].Bucket, decoded.Buckets[i].Bucket)
+	}
+}
+
+func TestWindowMerge(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	remote := NewWindow(WithSize(3), WithInterval(duration), WithClock(clk))
+	remote.Add(1)
+	elapse(clk)
+	remote.Add(2)
+
+	// 本地窗口晚半个桶创建，桶的边界与远端错开
+	clk.Advance(duration / 2)
+	local := NewWindow(WithSize(3), WithInterval(duration), WithClock(clk))
+	local.Add(10)
+
+	local.Merge(remote.Snapshot())
+	assert.Equal(t, int64(13), local.Reduce())
+	assert.Equal(t, int64(3), local.Count())
+
+	elapse(clk)
+	assert.Equal(t, int64(13), local.Reduce())
+	// 远端第一个桶按中点时间归入本地更早的桶，先于其他桶过期
+	elapse(clk)
+	assert.Equal(t, int64(12), local.Reduce())
+
+	// 滑出本地窗口的桶直接丢弃
+	old := WindowSnapshot{Interval: duration, Buckets: []BucketSnapshot{
+		{Start: clk.Now().Add(-10 * duration), Bucket: Bucket{Sum: 100, Count: 1, Min: 100, Max: 100}},
+	}}
+	local.Merge(old)
+	assert.Equal(t, int64(12), local.Reduce())
+}
@@ -0,0 +1,110 @@
+package rollingwindow
+
+import (
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-zero-source/util/clock"
+)
+
+// StripedWindow 分片滑动窗口，语义与 Window 相同，适用于大量协程并发 Add 的场景
+// 1. 按 GOMAXPROCS 划分多个分片，Add 随机选择一个分片，分散锁竞争
+// 2. 每个桶记录所属的刻度，刻度一致时只需读锁 + 原子累加，只有轮转桶时才需要写锁
+// 3. Reduce 时合并所有分片中未过期的桶
+type StripedWindow struct {
+	size     int
+	interval time.Duration
+	start    time.Time // 窗口创建时间，刻度的起点
+	shards   []*stripe
+	// 统计时忽略当前桶
+	ignoreCurrent bool
+	clock         clock.Clock
+}
+
+// stripe 单个分片
+type stripe struct {
+	lock    sync.RWMutex
+	buckets []stripedBucket
+	_       [64]byte // 避免相邻分片伪共享
+}
+
+// stripedBucket 带刻度的桶
+type stripedBucket struct {
+	tick int64 // 桶所属的刻度
+	val  int64 // 统计数据
+}
+
+func NewStripedWindow(opts ...WindowOption) *StripedWindow {
+	t := newTimeline(opts...)
+	w := &StripedWindow{
+		size:     t.size,
+		interval: t.interval,
+		start:    t.lastTime,
+		shards:   make([]*stripe, runtime.GOMAXPROCS(0)),
+		// 忽略当前桶
+		ignoreCurrent: t.ignoreCurrent,
+		clock:         t.clock,
+	}
+
+	for i := range w.shards {
+		w.shards[i] = &stripe{buckets: make([]stripedBucket, w.size)}
+	}
+
+	return w
+}
+
+// Add 添加统计值
+func (w *StripedWindow) Add(val int64) {
+	tick := w.tick()
+	s := w.shards[rand.Intn(len(w.shards))]
+	b := &s.buckets[tick%int64(w.size)]
+
+	// 快路径：桶属于当前刻度，直接原子累加
+	s.lock.RLock()
+	if atomic.LoadInt64(&b.tick) >= tick {
+		atomic.AddInt64(&b.val, val)
+		s.lock.RUnlock()
+		return
+	}
+	s.lock.RUnlock()
+
+	// 慢路径：桶已过期，重置后再累加
+	s.lock.Lock()
+	if b.tick < tick {
+		atomic.StoreInt64(&b.tick, tick)
+		atomic.StoreInt64(&b.val, 0)
+	}
+	atomic.AddInt64(&b.val, val)
+	s.lock.Unlock()
+}
+
+// Reduce 获取统计值，合并所有分片中未过期的桶
+func (w *StripedWindow) Reduce() int64 {
+	tick := w.tick()
+	oldest := tick - int64(w.size)
+
+	var sum int64
+	for _, s := range w.shards {
+		s.lock.RLock()
+		for i := range s.buckets {
+			b := &s.buckets[i]
+			bt := atomic.LoadInt64(&b.tick)
+			// 跳过过期的桶，忽略当前桶时同时跳过当前刻度的桶
+			if bt <= oldest || (w.ignoreCurrent && bt >= tick) {
+				continue
+			}
+			sum += atomic.LoadInt64(&b.val)
+		}
+		s.lock.RUnlock()
+	}
+
+	return sum
+}
+
+// tick 当前所处刻度，从 1 开始，0 表示桶从未使用过
+func (w *StripedWindow) tick() int64 {
+	return int64(w.clock.Since(w.start)/w.interval) + 1
+}
@@ -0,0 +1,149 @@
+package rollingwindow
+
+import (
+	"time"
+
+	"go-zero-source/util/clock"
+)
+
+// timeline 桶的轮转逻辑，Window 与 Histogram 共用
+// 只负责计算桶的位置，不关心桶里存放的数据
+type timeline struct {
+	size     int           // 桶的数量
+	interval time.Duration // 统计间隔
+	lastTime time.Time     // 上次轮转的时间，当前桶视为起始于该时间
+	current  int           // 当前所处桶
+	// 统计时忽略当前桶，当前桶的数据尚不完整，参考 go-zero 的 IgnoreCurrentBucket
+	ignoreCurrent bool
+	clock         clock.Clock // 时钟
+}
+
+type WindowOption func(opt *timeline)
+
+func WithSize(size int) WindowOption {
+	return func(opt *timeline) {
+		opt.size = size
+	}
+}
+
+func WithInterval(interval time.Duration) WindowOption {
+	return func(opt *timeline) {
+		opt.interval = interval
+	}
+}
+
+// WithIgnoreCurrent 统计时忽略当前桶
+func WithIgnoreCurrent() WindowOption {
+	return func(opt *timeline) {
+		opt.ignoreCurrent = true
+	}
+}
+
+// WithClock 指定时钟，测试时可以传入 clocktest.FakeClock
+func WithClock(c clock.Clock) WindowOption {
+	return func(opt *timeline) {
+		opt.clock = c
+	}
+}
+
+func newTimeline(opts ...WindowOption) timeline {
+	t := timeline{
+		size:     defaultSize,
+		interval: defaultInterval,
+		current:  0,
+		clock:    clock.Default,
+	}
+
+	for _, opt := range opts {
+		opt(&t)
+	}
+	t.lastTime = t.clock.Now()
+
+	return t
+}
+
+// rotate 计算当前所处桶位置
+// 偏移经过的位置都是已过期的桶，需要将 (t.current, t.current+offset] 通过 reset 置为 0
+func (t *timeline) rotate(reset func(idx int)) int {
+	// 计算偏移量
+	offset := t.span()
+	// 没动，直接返回当前桶位置
+	if offset <= 0 {
+		return t.current
+	}
+
+	// 将已过期的桶置为0
+	old := t.current + 1
+	for i := 0; i < offset; i++ {
+		// 取余为了循环
+		reset((old + i) % t.size)
+	}
+
+	// 计算新的当前桶位置
+	t.current = (t.current + offset) % t.size
+
+	// 更新上次更新时间
+	t.lastTime = t.clock.Now()
+
+	return t.current
+}
+
+// each 按时间先后遍历未过期的桶，返回参与统计的窗口长度
+func (t *timeline) each(fn func(idx int)) time.Duration {
+	return t.walk(t.ignoreCurrent, fn)
+}
+
+// walk 按时间先后遍历未过期的桶，ignoreCurrent 为 true 时跳过当前桶，返回参与统计的窗口长度
+// 与 go-zero 一致，只有当前时间仍处于 t.current 时才跳过，已经轮转过的桶数据是完整的
+// 窗口长度与遍历使用同一个偏移量，避免两次计算之间跨过桶的边界
+func (t *timeline) walk(ignoreCurrent bool, fn func(idx int)) time.Duration {
+	// 计算偏移量，偏移过的桶都是已过期的
+	offset := t.span()
+
+	// 需要计算的桶总数：桶的总数 - 过期桶数量
+	total := t.size - offset
+	length := time.Duration(t.size) * t.interval
+	if ignoreCurrent && offset == 0 {
+		total--
+		length -= t.interval
+	}
+	// 未过期的桶的起始位置
+	start := (t.current + offset + 1) % t.size
+	for i := 0; i < total; i++ {
+		fn((start + i) % t.size)
+	}
+
+	return length
+}
+
+// bucketStart 桶的起始时间，当前桶视为起始于上次轮转的时间 lastTime，往前每个桶早一个 interval
+func (t *timeline) bucketStart(idx int) time.Time {
+	dist := (t.current - idx + t.size) % t.size
+	return t.lastTime.Add(-time.Duration(dist) * t.interval)
+}
+
+// bucketAt 时间 at 所在的桶，at 早于窗口时返回 false，晚于当前桶时归入当前桶
+// 调用前需要先 rotate，保证当前桶即为当前时间所在的桶
+func (t *timeline) bucketAt(at time.Time) (int, bool) {
+	var dist int
+	if behind := t.lastTime.Sub(at); behind > 0 {
+		// 向上取整：at 落在 [lastTime - dist*interval, lastTime - (dist-1)*interval) 中
+		dist = int((behind + t.interval - 1) / t.interval)
+	}
+	if dist >= t.size {
+		return 0, false
+	}
+
+	return (t.current - dist + t.size) % t.size, true
+}
+
+// 计算偏移量
+func (t *timeline) span() int {
+	offset := int(t.clock.Since(t.lastTime) / t.interval)
+	if 0 <= offset && offset <= t.size {
+		return offset
+	}
+
+	// 最多计算一圈
+	return t.size
+}
@@ -0,0 +1,25 @@
+package clock
+
+import "time"
+
+// Clock 时钟，滑动窗口、熔断器等依赖时间的组件通过它获取当前时间
+// 测试时可以替换为 clocktest.FakeClock，手动推进时间
+type Clock interface {
+	// Now 当前时间
+	Now() time.Time
+	// Since 从 t 到现在经过的时间
+	Since(t time.Time) time.Duration
+}
+
+// Default 默认时钟，即系统时间
+var Default Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
@@ -0,0 +1,43 @@
+package clocktest
+
+import (
+	"sync"
+	"time"
+)
+
+// FakeClock 手动推进的时钟，仅用于测试
+type FakeClock struct {
+	lock sync.RWMutex
+	now  time.Time
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.now
+}
+
+func (c *FakeClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// Advance 将时间推进 d
+func (c *FakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+// Set 将时间设置为 t
+func (c *FakeClock) Set(t time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.now = t
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	breaker "go-zero-source/breaker/go-zero/source"
+)
+
+// BreakerCollector 熔断器指标采集器
+// 采集时遍历 breakers.go 的注册表，按熔断器名称输出累计的请求数、放行数、丢弃数，
+// 以及熔断器统计窗口内的丢弃比例，采集器本身不保存状态
+type BreakerCollector struct {
+	requests  *prometheus.Desc
+	accepts   *prometheus.Desc
+	drops     *prometheus.Desc
+	dropRatio *prometheus.Desc
+}
+
+func NewBreakerCollector() *BreakerCollector {
+	labels := []string{"name"}
+	return &BreakerCollector{
+		requests: prometheus.NewDesc("breaker_requests_total",
+			"Total number of requests passed through the breaker.", labels, nil),
+		accepts: prometheus.NewDesc("breaker_accepts_total",
+			"Total number of requests accepted by the breaker and succeeded.", labels, nil),
+		drops: prometheus.NewDesc("breaker_drops_total",
+			"Total number of requests dropped by the breaker.", labels, nil),
+		dropRatio: prometheus.NewDesc("breaker_drop_ratio",
+			"Ratio of dropped requests in the breaker's current window.", labels, nil),
+	}
+}
+
+// Describe 实现 prometheus.Collector
+func (c *BreakerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.requests
+	ch <- c.accepts
+	ch <- c.drops
+	ch <- c.dropRatio
+}
+
+// Collect 实现 prometheus.Collector
+func (c *BreakerCollector) Collect(ch chan<- prometheus.Metric) {
+	breaker.Range(func(name string, b breaker.Breaker, counts breaker.Counts) bool {
+		ch <- prometheus.MustNewConstMetric(c.requests, prometheus.CounterValue, float64(counts.Requests()), name)
+		ch <- prometheus.MustNewConstMetric(c.accepts, prometheus.CounterValue, float64(counts.Accepts), name)
+		ch <- prometheus.MustNewConstMetric(c.drops, prometheus.CounterValue, float64(counts.Drops), name)
+		ch <- prometheus.MustNewConstMetric(c.dropRatio, prometheus.GaugeValue, breaker.DropRatio(b), name)
+
+		return true
+	})
+}
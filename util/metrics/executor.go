@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	executors "go-zero-source/executor/go-zero/source"
+)
+
+// ExecutorCollector PeriodicalExecutor 指标采集器，按执行器名称输出执行次数、批大小和执行耗时
+type ExecutorCollector struct {
+	flushes   *prometheus.CounterVec
+	batchSize *prometheus.HistogramVec
+	latency   *prometheus.HistogramVec
+}
+
+func NewExecutorCollector() *ExecutorCollector {
+	labels := []string{"name"}
+	return &ExecutorCollector{
+		flushes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "executor_flushes_total",
+			Help: "Total number of task batches executed.",
+		}, labels),
+		batchSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "executor_batch_size",
+			Help:    "Number of tasks in each executed batch.",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 12),
+		}, labels),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "executor_execute_duration_seconds",
+			Help:    "Time spent executing each batch.",
+			Buckets: prometheus.DefBuckets,
+		}, labels),
+	}
+}
+
+// Observe 采集执行器 pe 的指标，name 作为指标的 name 标签
+// 会覆盖 pe 上已设置的 OnExecute 回调，需要在第一次 Add 之前调用
+func (c *ExecutorCollector) Observe(name string, pe *executors.PeriodicalExecutor) {
+	flushes := c.flushes.WithLabelValues(name)
+	batchSize := c.batchSize.WithLabelValues(name)
+	latency := c.latency.WithLabelValues(name)
+
+	pe.OnExecute(func(size int, elapsed time.Duration) {
+		flushes.Inc()
+		batchSize.Observe(float64(size))
+		latency.Observe(elapsed.Seconds())
+	})
+}
+
+// Describe 实现 prometheus.Collector
+func (c *ExecutorCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.flushes.Describe(ch)
+	c.batchSize.Describe(ch)
+	c.latency.Describe(ch)
+}
+
+// Collect 实现 prometheus.Collector
+func (c *ExecutorCollector) Collect(ch chan<- prometheus.Metric) {
+	c.flushes.Collect(ch)
+	c.batchSize.Collect(ch)
+	c.latency.Collect(ch)
+}
@@ -0,0 +1,139 @@
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/stringx"
+
+	breaker "go-zero-source/breaker/go-zero/source"
+	executors "go-zero-source/executor/go-zero/source"
+)
+
+func TestBreakerCollector(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(NewBreakerCollector())
+
+	// 熔断器注册表是全局的，使用唯一的名称避免重复运行时互相影响
+	name := stringx.Rand()
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, breaker.Do(name, func() error {
+			return nil
+		}))
+	}
+	assert.NotNil(t, breaker.Do(name, func() error {
+		return errors.New("any")
+	}))
+
+	families, err := reg.Gather()
+	assert.Nil(t, err)
+	assert.Equal(t, float64(4), valueOf(families, "breaker_requests_total", name))
+	assert.Equal(t, float64(3), valueOf(families, "breaker_accepts_total", name))
+	assert.Equal(t, float64(0), valueOf(families, "breaker_drops_total", name))
+	assert.Equal(t, float64(0), valueOf(families, "breaker_drop_ratio", name))
+
+	// 持续失败直到开始丢弃请求，全部请求都在统计窗口内，丢弃比例等于累计值之比
+	for i := 0; i < 100; i++ {
+		_ = breaker.Do(name, func() error {
+			return errors.New("any")
+		})
+	}
+	families, err = reg.Gather()
+	assert.Nil(t, err)
+	drops := valueOf(families, "breaker_drops_total", name)
+	assert.True(t, drops > 0)
+	assert.InDelta(t, drops/valueOf(families, "breaker_requests_total", name),
+		valueOf(families, "breaker_drop_ratio", name), 1e-9)
+
+	// 采集器不保存状态，重复采集结果一致
+	again, err := reg.Gather()
+	assert.Nil(t, err)
+	assert.Equal(t, families, again)
+}
+
+// valueOf 查找指标 metric 中标签 name 对应的值
+func valueOf(families []*dto.MetricFamily, metric, name string) float64 {
+	for _, family := range families {
+		if family.GetName() != metric {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			if m.GetLabel()[0].GetValue() != name {
+				continue
+			}
+			if m.GetCounter() != nil {
+				return m.GetCounter().GetValue()
+			}
+			return m.GetGauge().GetValue()
+		}
+	}
+
+	return -1
+}
+
+type container struct {
+	lock  sync.Mutex
+	tasks []any
+	max   int
+}
+
+func (c *container) AddTask(task any) bool {
+	c.tasks = append(c.tasks, task)
+	return len(c.tasks) >= c.max
+}
+
+func (c *container) Execute(tasks any) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	time.Sleep(time.Millisecond)
+}
+
+func (c *container) RemoveAll() any {
+	tasks := c.tasks
+	c.tasks = nil
+	return tasks
+}
+
+func TestExecutorCollector(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	collector := NewExecutorCollector()
+	reg.MustRegister(collector)
+
+	pe := executors.NewPeriodicalExecutor(time.Hour, &container{max: 2})
+	collector.Observe("bulk", pe)
+	for i := 0; i < 5; i++ {
+		pe.Add(i)
+	}
+	pe.Flush()
+	pe.Wait()
+
+	// 两个满批次 + Flush 的一个批次
+	expected := `
+# HELP executor_flushes_total Total number of task batches executed.
+# TYPE executor_flushes_total counter
+executor_flushes_total{name="bulk"} 3
+`
+	assert.Nil(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "executor_flushes_total"))
+
+	families, err := reg.Gather()
+	assert.Nil(t, err)
+	for _, family := range families {
+		switch family.GetName() {
+		case "executor_batch_size":
+			h := family.GetMetric()[0].GetHistogram()
+			assert.Equal(t, uint64(3), h.GetSampleCount())
+			assert.Equal(t, float64(5), h.GetSampleSum())
+		case "executor_execute_duration_seconds":
+			h := family.GetMetric()[0].GetHistogram()
+			assert.Equal(t, uint64(3), h.GetSampleCount())
+			assert.GreaterOrEqual(t, h.GetSampleSum(), 3*time.Millisecond.Seconds())
+		}
+	}
+}